@@ -0,0 +1,285 @@
+// Package arc 实现了按字节限制容量的 ARC（Adaptive Replacement Cache）淘汰策略，满足 lru.Store 接口。
+//
+// ARC 维护四个链表：
+//   - T1：只访问过一次的记录（近期性）
+//   - T2：至少访问过两次的记录（频率）
+//   - B1、B2：分别是最近从 T1、T2 淘汰的记录的"幽灵"，只保留 key 和大小，不占用缓存容量
+//
+// 命中 B1 说明 T1 太小，增大 T1 的目标大小 p；命中 B2 则减小 p。
+// 一次性的批量扫描只会进入 T1 并在 T1 内部互相淘汰，不会冲掉 T2 中的热点数据。
+package arc
+
+import (
+	"container/list"
+	"geecache/lru"
+	"time"
+)
+
+type Cache struct {
+	maxBytes       int64
+	p              int64 // T1 的目标字节数，在 [0, maxBytes] 内自适应调整
+	t1, t2, b1, b2 *queue
+	items          map[string]*list.Element // 四个链表中的所有 key
+	OnEvicted      func(key string, value lru.Value)
+	// OnEvictedWithReason 与 OnEvicted 相同，但额外告知淘汰原因（容量不足或过期）
+	OnEvictedWithReason func(key string, value lru.Value, reason lru.EvictReason)
+	// Now 返回当前时间，用于判断过期；为 nil 时使用 time.Now
+	Now func() time.Time
+}
+
+// queue 是带字节统计的链表，头部是最近使用的
+type queue struct {
+	ll    *list.List // 元素是 *entry
+	bytes int64
+}
+
+type entry struct {
+	key    string
+	value  lru.Value // 在 B1、B2 中为 nil
+	size   int64     // len(key) + value.Len()
+	expire time.Time // 零值表示永不过期
+	q      *queue    // 所在的链表
+}
+
+func New(maxBytes int64, onEvicted func(string, lru.Value)) *Cache {
+	return &Cache{
+		maxBytes:  maxBytes,
+		t1:        &queue{ll: list.New()},
+		t2:        &queue{ll: list.New()},
+		b1:        &queue{ll: list.New()},
+		b2:        &queue{ll: list.New()},
+		items:     make(map[string]*list.Element),
+		OnEvicted: onEvicted,
+	}
+}
+
+// NewStore 是 ARC 策略的 lru.Policy
+func NewStore(maxBytes int64, onEvicted func(key string, value lru.Value)) lru.Store {
+	return New(maxBytes, onEvicted)
+}
+
+var (
+	_ lru.Store          = (*Cache)(nil)
+	_ lru.Victimer       = (*Cache)(nil)
+	_ lru.Clocked        = (*Cache)(nil)
+	_ lru.ReasonNotifier = (*Cache)(nil)
+)
+
+// Add 添加一条永不过期的记录
+func (c *Cache) Add(key string, value lru.Value) {
+	c.AddWithTTL(key, value, 0)
+}
+
+// AddWithTTL 添加一条记录，ttl 之后过期；ttl <= 0 表示永不过期
+func (c *Cache) AddWithTTL(key string, value lru.Value, ttl time.Duration) {
+	var expire time.Time
+	if ttl > 0 {
+		expire = c.now().Add(ttl)
+	}
+	size := int64(len(key)) + int64(value.Len())
+
+	target, inB2 := c.t1, false
+	if ele, ok := c.items[key]; ok {
+		e := ele.Value.(*entry)
+		switch e.q {
+		case c.b1: // T1 淘汰得太早了，增大 T1 的目标大小
+			c.p = min(c.maxBytes, c.p+ghostDelta(c.b2.bytes, c.b1.bytes, size))
+		case c.b2: // T2 淘汰得太早了，减小 T1 的目标大小
+			c.p = max(0, c.p-ghostDelta(c.b1.bytes, c.b2.bytes, size))
+			inB2 = true
+		}
+		// 已存在（无论是在缓存中还是幽灵）说明至少第二次出现，进入 T2
+		e.q.remove(ele)
+		delete(c.items, key)
+		target = c.t2
+	}
+
+	// 先腾出空间再插入，避免新记录把自己淘汰掉
+	for c.maxBytes != 0 && c.t1.bytes+c.t2.bytes+size > c.maxBytes && c.t1.ll.Len()+c.t2.ll.Len() > 0 {
+		c.replace(inB2)
+	}
+	c.items[key] = target.pushFront(&entry{key: key, value: value, size: size, expire: expire})
+	// 单条记录就超过 maxBytes 的情况
+	for c.maxBytes != 0 && c.t1.bytes+c.t2.bytes > c.maxBytes {
+		c.replace(inB2)
+	}
+	c.trimGhosts()
+}
+
+// Get 查找 key，命中后移到 T2 头部；已过期的记录会在这里被惰性删除并视为未命中
+func (c *Cache) Get(key string) (value lru.Value, ok bool) {
+	ele, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	e := ele.Value.(*entry)
+	if e.q == c.b1 || e.q == c.b2 {
+		return nil, false
+	}
+	if e.expired(c.now()) {
+		c.removeResident(ele, lru.EvictExpired)
+		return nil, false
+	}
+	e.q.remove(ele)
+	c.items[key] = c.t2.pushFront(e)
+	return e.value, true
+}
+
+// Contains 判断 key 是否在 T1、T2 中，不移动它的位置；幽灵记录不算
+func (c *Cache) Contains(key string) bool {
+	ele, ok := c.items[key]
+	if !ok {
+		return false
+	}
+	q := ele.Value.(*entry).q
+	return q == c.t1 || q == c.t2
+}
+
+// Victim 返回插入一条新记录时下一个会被淘汰的 key，与 replace(false) 的选择一致
+func (c *Cache) Victim() (key string, ok bool) {
+	q := c.t2
+	if c.t1.ll.Len() > 0 && (c.t1.bytes > c.p || c.t2.ll.Len() == 0) {
+		q = c.t1
+	}
+	if ele := q.ll.Back(); ele != nil {
+		return ele.Value.(*entry).key, true
+	}
+	return "", false
+}
+
+// Remove 主动删除 T1、T2 中的 key，返回它是否在缓存中；不调用 OnEvicted。
+// 幽灵记录保留，它只影响 p 的调整，不占用缓存空间
+func (c *Cache) Remove(key string) bool {
+	ele, ok := c.items[key]
+	if !ok {
+		return false
+	}
+	e := ele.Value.(*entry)
+	if e.q == c.b1 || e.q == c.b2 {
+		return false
+	}
+	e.q.remove(ele)
+	delete(c.items, key)
+	return true
+}
+
+// RemoveExpired 删除 T1、T2 中所有已过期的记录，返回回收的字节数
+func (c *Cache) RemoveExpired() int64 {
+	now := c.now()
+	var freed int64
+	for _, q := range []*queue{c.t1, c.t2} {
+		for ele := q.ll.Back(); ele != nil; {
+			prev := ele.Prev()
+			if e := ele.Value.(*entry); e.expired(now) {
+				freed += e.size
+				c.removeResident(ele, lru.EvictExpired)
+			}
+			ele = prev
+		}
+	}
+	return freed
+}
+
+func (c *Cache) Len() int {
+	return c.t1.ll.Len() + c.t2.ll.Len()
+}
+
+// Bytes 返回当前已使用的字节数（key + value），不包括幽灵记录
+func (c *Cache) Bytes() int64 {
+	return c.t1.bytes + c.t2.bytes
+}
+
+// replace 淘汰一条记录：T1 超过目标大小 p 时淘汰 T1 的尾部到 B1，否则淘汰 T2 的尾部到 B2
+func (c *Cache) replace(inB2 bool) {
+	if c.t1.ll.Len() > 0 && (c.t1.bytes > c.p || (inB2 && c.t1.bytes == c.p) || c.t2.ll.Len() == 0) {
+		c.demote(c.t1.ll.Back(), c.b1)
+	} else {
+		c.demote(c.t2.ll.Back(), c.b2)
+	}
+}
+
+// demote 把缓存中的记录移到幽灵链表，只保留 key 和大小
+func (c *Cache) demote(ele *list.Element, ghost *queue) {
+	e := ele.Value.(*entry)
+	e.q.remove(ele)
+	value := e.value
+	e.value = nil
+	c.items[e.key] = ghost.pushFront(e)
+	c.evicted(e.key, value, lru.EvictCapacity)
+}
+
+// trimGhosts 限制幽灵链表的大小：T1+B1 不超过 maxBytes，四个链表合计不超过 2*maxBytes
+func (c *Cache) trimGhosts() {
+	if c.maxBytes == 0 {
+		return
+	}
+	for c.b1.ll.Len() > 0 && c.t1.bytes+c.b1.bytes > c.maxBytes {
+		c.dropGhost(c.b1)
+	}
+	for c.b2.ll.Len() > 0 && c.t1.bytes+c.t2.bytes+c.b1.bytes+c.b2.bytes > 2*c.maxBytes {
+		c.dropGhost(c.b2)
+	}
+}
+
+func (c *Cache) dropGhost(ghost *queue) {
+	ele := ghost.ll.Back()
+	ghost.remove(ele)
+	delete(c.items, ele.Value.(*entry).key)
+}
+
+// removeResident 直接删除 T1、T2 中的记录，不进入幽灵链表
+func (c *Cache) removeResident(ele *list.Element, reason lru.EvictReason) {
+	e := ele.Value.(*entry)
+	e.q.remove(ele)
+	delete(c.items, e.key)
+	c.evicted(e.key, e.value, reason)
+}
+
+func (c *Cache) evicted(key string, value lru.Value, reason lru.EvictReason) {
+	if c.OnEvicted != nil {
+		c.OnEvicted(key, value)
+	}
+	if c.OnEvictedWithReason != nil {
+		c.OnEvictedWithReason(key, value, reason)
+	}
+}
+
+// SetOnEvictedWithReason 设置 OnEvictedWithReason（实现了 lru.ReasonNotifier 接口）
+func (c *Cache) SetOnEvictedWithReason(fn func(key string, value lru.Value, reason lru.EvictReason)) {
+	c.OnEvictedWithReason = fn
+}
+
+// SetNow 替换判断过期用的时钟，nil 表示使用 time.Now（实现了 lru.Clocked 接口）
+func (c *Cache) SetNow(now func() time.Time) {
+	c.Now = now
+}
+
+func (c *Cache) now() time.Time {
+	if c.Now != nil {
+		return c.Now()
+	}
+	return time.Now()
+}
+
+// ghostDelta 计算命中幽灵链表时 p 的调整量：另一个幽灵链表越大，调整越多
+func ghostDelta(other, hit, size int64) int64 {
+	if hit > 0 && other > hit {
+		return other / hit * size
+	}
+	return size
+}
+
+func (q *queue) pushFront(e *entry) *list.Element {
+	e.q = q
+	q.bytes += e.size
+	return q.ll.PushFront(e)
+}
+
+func (q *queue) remove(ele *list.Element) {
+	q.ll.Remove(ele)
+	q.bytes -= ele.Value.(*entry).size
+}
+
+func (e *entry) expired(now time.Time) bool {
+	return !e.expire.IsZero() && !now.Before(e.expire)
+}
@@ -0,0 +1,97 @@
+package arc
+
+import (
+	"fmt"
+	"geecache/lru"
+	"geecache/lru/storetest"
+	"testing"
+)
+
+type String string
+
+func (d String) Len() int {
+	return len(d)
+}
+
+func TestStore(t *testing.T) {
+	storetest.TestStore(t, NewStore)
+}
+
+// TestScanResistance 一次批量扫描不应冲掉被反复访问的热点数据，而普通 LRU 会被冲掉
+func TestScanResistance(t *testing.T) {
+	const entrySize = int64(len("hot0") + len("v"))
+	hot := []string{"hot0", "hot1", "hot2", "hot3", "hot4"}
+
+	for _, tc := range []struct {
+		name      string
+		policy    lru.Policy
+		expectHot bool
+	}{
+		{"arc", NewStore, true},
+		{"lru", lru.NewStore, false},
+	} {
+		s := tc.policy(entrySize*10, nil)
+		for _, k := range hot {
+			s.Add(k, String("v"))
+			s.Get(k)
+		}
+		for i := 0; i < 100; i++ {
+			s.Add(fmt.Sprintf("s%03d", i), String("v"))
+		}
+		for _, k := range hot {
+			if _, ok := s.Get(k); ok != tc.expectHot {
+				t.Fatalf("%s: hot key %s present = %v, want %v", tc.name, k, ok, tc.expectHot)
+			}
+		}
+	}
+}
+
+// TestGhostHit 命中 B1 后记录进入 T2，且 T1 的目标大小 p 增大
+func TestGhostHit(t *testing.T) {
+	c := New(int64(len("k1v1")*4), nil)
+	c.Add("k1", String("v1"))
+	c.Add("k2", String("v2"))
+	c.Get("k1")
+	c.Get("k2")               // k1、k2 进入 T2
+	c.Add("k3", String("v3")) // k3、k4 在 T1
+	c.Add("k4", String("v4"))
+	c.Add("k5", String("v5")) // T1 超过 p，k3 被淘汰到 B1
+
+	if _, ok := c.Get("k3"); ok {
+		t.Fatalf("k3 should be evicted")
+	}
+	if e := c.items["k3"].Value.(*entry); e.q != c.b1 {
+		t.Fatalf("k3 should be in B1")
+	}
+
+	c.Add("k3", String("v3"))
+	if e := c.items["k3"].Value.(*entry); e.q != c.t2 {
+		t.Fatalf("k3 should move to T2 after ghost hit")
+	}
+	if c.p == 0 {
+		t.Fatalf("p should grow after B1 hit")
+	}
+	if c.Bytes() > c.maxBytes {
+		t.Fatalf("bytes %d exceed maxBytes %d", c.Bytes(), c.maxBytes)
+	}
+}
+
+// TestVictim Victim 预测的 key 正是插入新记录时被淘汰的那一个
+func TestVictim(t *testing.T) {
+	var evicted []string
+	c := New(int64(len("k0")+len("v"))*4, func(key string, _ lru.Value) { evicted = append(evicted, key) })
+	if _, ok := c.Victim(); ok {
+		t.Fatal("empty cache should have no victim")
+	}
+	for i := 0; i < 4; i++ {
+		c.Add(fmt.Sprintf("k%d", i), String("v"))
+	}
+	c.Get("k0") // k0 进入 T2
+	for i := 4; i < 8; i++ {
+		victim, ok := c.Victim()
+		c.Add(fmt.Sprintf("k%d", i), String("v"))
+		if !ok || len(evicted) == 0 || evicted[len(evicted)-1] != victim {
+			t.Fatalf("Victim() = %q, but %v was evicted", victim, evicted)
+		}
+	}
+}
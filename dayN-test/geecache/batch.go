@@ -0,0 +1,190 @@
+package geecache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+)
+
+// BatchGetter 由能一次加载多个 key 的 Getter 实现（例如一条 SQL 的 IN 查询）。
+// 传给 NewGroup 的 Getter 若同时实现了它，GetMany 会用一次调用加载所有本地负责的 key。
+// 结果中既没有值也没有错误的 key 视为加载失败
+type BatchGetter interface {
+	GetMany(ctx context.Context, keys []string) (values map[string][]byte, errs map[string]error)
+}
+
+// GetMany 一次获取多个 key，等价于 GetManyContext(context.Background(), keys)
+func (g *Group) GetMany(keys []string) (map[string]ByteView, map[string]error) {
+	return g.GetManyContext(context.Background(), keys)
+}
+
+// GetManyContext 一次获取多个 key，返回成功的值和每个失败的 key 的错误：
+//  1. 先查本地缓存和负缓存；
+//  2. 未命中的 key 按 PickPeer（或 BatchPeerPicker）选出的节点分组，每个支持 BatchPeerGetter 的节点只发一次批量请求，
+//     不支持的节点逐个 key 走 Get 的流程；
+//  3. 本地负责的 key 以及因节点不可用而失败（不含 ErrNotFound、ErrRemoteLoad）的 key 在本地加载，Getter 实现了 BatchGetter 时只调用一次。
+func (g *Group) GetManyContext(ctx context.Context, keys []string) (map[string]ByteView, map[string]error) {
+	values := make(map[string]ByteView, len(keys))
+	errs := make(map[string]error)
+
+	var misses []string
+	for _, key := range keys {
+		if _, ok := values[key]; ok || errs[key] != nil {
+			continue // 重复的 key
+		}
+		if key == "" {
+			errs[key] = fmt.Errorf("key is required")
+			continue
+		}
+		g.Stats.Gets.Add(1)
+		if v, ok := g.lookupCache(key); ok {
+			g.Stats.CacheHits.Add(1)
+			values[key] = v
+			continue
+		}
+		if err := g.lookupNegative(key); err != nil {
+			g.Stats.NegativeHits.Add(1)
+			errs[key] = err
+			continue
+		}
+		misses = append(misses, key)
+	}
+
+	var mu sync.Mutex // 保护 values、errs 和 local
+	var wg sync.WaitGroup
+	var local []string
+	for peer, keys := range g.partitionByPeer(ctx, misses) {
+		if peer == nil {
+			local = append(local, keys...)
+			continue
+		}
+		bp, ok := peer.(BatchPeerGetter)
+		if !ok {
+			// 不支持批量的节点，逐个 key 走 Get 的完整流程（含故障转移）
+			for _, key := range keys {
+				wg.Add(1)
+				go func(key string) {
+					defer wg.Done()
+					v, err := g.load(ctx, key)
+					mu.Lock()
+					defer mu.Unlock()
+					setResult(values, errs, key, v, err)
+				}(key)
+			}
+			continue
+		}
+		wg.Add(1)
+		go func(bp BatchPeerGetter, keys []string) {
+			defer wg.Done()
+			vals, perrs := bp.GetMany(ctx, g.name, keys)
+			mu.Lock()
+			defer mu.Unlock()
+			for _, key := range keys {
+				if b, ok := vals[key]; ok && perrs[key] == nil {
+					g.Stats.PeerLoads.Add(1)
+					v := ByteView{b: b}
+					if rand.Intn(100) < hotCachePercent {
+						g.hotCache.add(key, v)
+					}
+					values[key] = v
+					continue
+				}
+				if errors.Is(perrs[key], ErrNotFound) {
+					errs[key] = g.recordNotFound(key, perrs[key])
+					continue
+				}
+				g.Stats.PeerErrors.Add(1)
+				if errors.Is(perrs[key], ErrRemoteLoad) {
+					errs[key] = perrs[key]
+					continue
+				}
+				// 与 Get 一样，节点不可用时回退到本地加载
+				local = append(local, key)
+			}
+		}(bp, keys)
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		for _, key := range local {
+			errs[key] = ctx.Err()
+		}
+		return values, errs
+	}
+	g.getManyLocally(ctx, local, values, errs)
+	return values, errs
+}
+
+// partitionByPeer 按负责节点给 key 分组，nil 对应本节点负责的 key。
+// PeerPicker 实现了 BatchPeerPicker 时用 PickBatchPeer 选点，避免按 key 包装的 peer 把同一节点拆成多组
+func (g *Group) partitionByPeer(ctx context.Context, keys []string) map[PeerGetter][]string {
+	parts := make(map[PeerGetter][]string)
+	bp, batch := g.peers.(BatchPeerPicker)
+	for _, key := range keys {
+		var peer PeerGetter
+		if g.peers != nil && !NoForward(ctx) {
+			if batch {
+				peer, _ = bp.PickBatchPeer(key)
+			} else {
+				peer, _ = g.peers.PickPeer(key)
+			}
+		}
+		parts[peer] = append(parts[peer], key)
+	}
+	return parts
+}
+
+// getManyLocally 在本地加载 keys，结果写入 values 和 errs
+func (g *Group) getManyLocally(ctx context.Context, keys []string, values map[string]ByteView, errs map[string]error) {
+	if len(keys) == 0 {
+		return
+	}
+	if g.batch != nil {
+		vals, berrs := g.batch.GetMany(ctx, keys)
+		for _, key := range keys {
+			b, ok := vals[key]
+			err := berrs[key]
+			if err == nil && !ok {
+				err = fmt.Errorf("key %q missing from batch result", key)
+			}
+			if errors.Is(err, ErrNotFound) {
+				errs[key] = g.recordNotFound(key, err)
+				continue
+			}
+			if err != nil {
+				g.Stats.LocalLoadErrs.Add(1)
+				errs[key] = err
+				continue
+			}
+			g.Stats.LocalLoads.Add(1)
+			values[key] = g.populateCache(key, ByteView{b: cloneBytes(b)})
+		}
+		return
+	}
+
+	// 逐个 key 加载，借助 loader 与并发的 Get 合并；WithNoForward 保证只在本地加载
+	ctx = WithNoForward(ctx)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, key := range keys {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			v, err := g.load(ctx, key)
+			mu.Lock()
+			defer mu.Unlock()
+			setResult(values, errs, key, v, err)
+		}(key)
+	}
+	wg.Wait()
+}
+
+func setResult(values map[string]ByteView, errs map[string]error, key string, v ByteView, err error) {
+	if err != nil {
+		errs[key] = err
+		return
+	}
+	values[key] = v
+}
@@ -0,0 +1,133 @@
+package geecache
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// batchGetterFunc 同时实现 Getter 和 BatchGetter，记录批量调用的次数
+type batchGetterFunc struct {
+	calls int32
+	get   func(key string) ([]byte, error)
+}
+
+func (f *batchGetterFunc) Get(key string) ([]byte, error) {
+	return f.get(key)
+}
+
+func (f *batchGetterFunc) GetMany(ctx context.Context, keys []string) (map[string][]byte, map[string]error) {
+	atomic.AddInt32(&f.calls, 1)
+	values, errs := make(map[string][]byte), make(map[string]error)
+	for _, key := range keys {
+		if v, err := f.get(key); err != nil {
+			errs[key] = err
+		} else {
+			values[key] = v
+		}
+	}
+	return values, errs
+}
+
+func dbGet(key string) ([]byte, error) {
+	if v, ok := db[key]; ok {
+		return []byte(v), nil
+	}
+	return nil, errors.New(key + " not exist")
+}
+
+func TestGetManyBatchGetter(t *testing.T) {
+	getter := &batchGetterFunc{get: dbGet}
+	gee := NewGroup("batch-local", 2<<10, getter)
+
+	values, errs := gee.GetMany([]string{"Tom", "Jack", "Tom", "unknown", ""})
+	if len(values) != 2 || values["Tom"].String() != "630" || values["Jack"].String() != "589" {
+		t.Fatalf("unexpected values %v", values)
+	}
+	if len(errs) != 2 || errs["unknown"] == nil || errs[""] == nil {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+	if getter.calls != 1 {
+		t.Fatalf("expect one batch load, got %d", getter.calls)
+	}
+
+	// 第二次全部命中缓存，不再调用 BatchGetter
+	values, _ = gee.GetMany([]string{"Tom", "Jack"})
+	if len(values) != 2 || getter.calls != 1 || gee.Stats.CacheHits.Get() != 2 {
+		t.Fatalf("expect cache hits, got %v after %d batch loads", values, getter.calls)
+	}
+}
+
+func TestGetManyGetter(t *testing.T) {
+	var loads int32
+	gee := NewGroup("batch-single", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		atomic.AddInt32(&loads, 1)
+		return dbGet(key)
+	}))
+
+	values, errs := gee.GetMany([]string{"Tom", "Jack", "Sam", "unknown"})
+	if len(values) != 3 || len(errs) != 1 || errs["unknown"] == nil {
+		t.Fatalf("unexpected result %v %v", values, errs)
+	}
+	if loads != 4 {
+		t.Fatalf("expect each key loaded once, got %d", loads)
+	}
+}
+
+// TestGetManyPeers 远程节点负责的 key 合并为一次批量请求，远程 Getter 失败的 key 直接返回错误
+func TestGetManyPeers(t *testing.T) {
+	remote := NewGroup("batch-remote", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		if key == "bad" {
+			return nil, errors.New("remote failure")
+		}
+		return []byte("remote-" + key), nil
+	}))
+	srv := httptest.NewServer(NewHTTPPool(""))
+	defer srv.Close()
+
+	pool := NewHTTPPool("http://self")
+	pool.Set(srv.URL)
+	local := newLocalGroup("batch-remote", GetterFunc(func(key string) ([]byte, error) {
+		return []byte("local-" + key), nil
+	}))
+	local.RegisterPeers(pool)
+
+	values, errs := local.GetMany([]string{"Tom", "Jack", "bad"})
+	if len(errs) != 1 || !errors.Is(errs["bad"], ErrRemoteLoad) || errs["bad"].Error() != "remote failure" {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+	want := map[string]string{"Tom": "remote-Tom", "Jack": "remote-Jack"}
+	for key, v := range want {
+		if values[key].String() != v {
+			t.Fatalf("%s = %q, want %q", key, values[key].String(), v)
+		}
+	}
+	if n := remote.Stats.ServerRequests.Get(); n != 1 {
+		t.Fatalf("expect one batch request, got %d", n)
+	}
+	if local.Stats.PeerLoads.Get() != 2 || local.Stats.PeerErrors.Get() != 1 || local.Stats.LocalLoads.Get() != 0 {
+		t.Fatalf("unexpected stats %+v", local.Stats)
+	}
+}
+
+// TestGetManyPeerDown 远程节点不可用时，它负责的 key 回退到本地加载
+func TestGetManyPeerDown(t *testing.T) {
+	dead := httptest.NewServer(nil)
+	dead.Close()
+	pool := NewHTTPPool("http://self")
+	pool.Set(dead.URL)
+	local := newLocalGroup("batch-down", GetterFunc(func(key string) ([]byte, error) {
+		return []byte("local-" + key), nil
+	}))
+	local.RegisterPeers(pool)
+
+	values, errs := local.GetMany([]string{"Tom", "Jack"})
+	if len(errs) != 0 || values["Tom"].String() != "local-Tom" || values["Jack"].String() != "local-Jack" {
+		t.Fatalf("unexpected result %v %v", values, errs)
+	}
+	if local.Stats.PeerErrors.Get() != 2 || local.Stats.LocalLoads.Get() != 2 {
+		t.Fatalf("unexpected stats %+v", &local.Stats)
+	}
+}
@@ -0,0 +1,169 @@
+package geecache
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// errCircuitOpen 熔断器打开时，httpGetter 不发起请求直接返回该错误
+var errCircuitOpen = errors.New("geecache: circuit breaker is open")
+
+// CircuitBreakerOptions 是每个远程节点熔断器的参数
+type CircuitBreakerOptions struct {
+	Window      time.Duration // 统计失败率的时间窗口
+	MinRequests int           // 窗口内请求数达到该值才判断失败率，避免少量请求误判
+	FailureRate float64       // 失败率达到该值时熔断，取值 (0,1]
+	Cooldown    time.Duration // 熔断后等待多久进入半开状态，放行一个探测请求
+}
+
+var defaultBreakerOptions = CircuitBreakerOptions{
+	Window:      10 * time.Second,
+	MinRequests: 5,
+	FailureRate: 0.5,
+	Cooldown:    5 * time.Second,
+}
+
+type breakerState int
+
+const (
+	breakerClosed   breakerState = iota // 正常放行，统计失败率
+	breakerOpen                         // 熔断，直接拒绝
+	breakerHalfOpen                     // 冷却结束，只放行一个探测请求
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerClosed:
+		return "closed"
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// circuitBreaker 是单个远程节点的熔断器：
+// closed 状态下窗口内失败率过高则 open；open 经过 Cooldown 后变为 half-open，
+// 放行一个探测请求，成功则 closed，失败则重新 open。
+// 每次状态切换 gen 加一，allow 把当时的 gen 交给请求，record 丢弃 gen 已过期的结果，
+// 这样熔断前发出、在 half-open 期间才完成的慢请求不会被当作探测结果
+type circuitBreaker struct {
+	mu   sync.Mutex
+	opts CircuitBreakerOptions
+	now  func() time.Time // 测试中可替换为假时钟
+
+	state       breakerState
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probing     bool   // half-open 时是否已有探测请求在进行
+	gen         uint64 // 状态切换的次数
+}
+
+func newCircuitBreaker(opts CircuitBreakerOptions) *circuitBreaker {
+	return &circuitBreaker{opts: opts, now: time.Now}
+}
+
+// ready 判断是否值得把请求路由到该节点，不改变状态，供 PickPeer 使用
+func (b *circuitBreaker) ready() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		return b.now().Sub(b.openedAt) >= b.opts.Cooldown
+	case breakerHalfOpen:
+		return !b.probing
+	}
+	return true
+}
+
+// allow 在发起请求前调用，返回 false 时不应发起请求；返回的 gen 在请求结束后原样传给 record 或 abort。
+// 冷却结束后第一个调用者成为探测请求
+func (b *circuitBreaker) allow() (gen uint64, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if b.now().Sub(b.openedAt) < b.opts.Cooldown {
+			return 0, false
+		}
+		b.state = breakerHalfOpen
+		b.gen++
+		b.probing = true
+	case breakerHalfOpen:
+		if b.probing {
+			return 0, false
+		}
+		b.probing = true
+	}
+	return b.gen, true
+}
+
+// record 记录一次请求的结果，gen 是 allow 返回的值；状态切换之前发出的请求被忽略
+func (b *circuitBreaker) record(gen uint64, success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if gen != b.gen {
+		return
+	}
+	now := b.now()
+	if b.state == breakerHalfOpen {
+		b.probing = false
+		if success {
+			b.reset(breakerClosed, now)
+		} else {
+			b.reset(breakerOpen, now)
+		}
+		return
+	}
+	if b.state == breakerOpen {
+		return
+	}
+
+	if now.Sub(b.windowStart) >= b.opts.Window {
+		b.reset(breakerClosed, now)
+	}
+	b.requests++
+	if !success {
+		b.failures++
+	}
+	if b.requests >= b.opts.MinRequests && float64(b.failures) >= b.opts.FailureRate*float64(b.requests) {
+		b.reset(breakerOpen, now)
+	}
+}
+
+// abort 用于调用方自己取消的请求：不计入统计，取消的是探测请求时允许发起新的探测
+func (b *circuitBreaker) abort(gen uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if gen == b.gen {
+		b.probing = false
+	}
+}
+
+// reset 切换状态并开始新的统计窗口
+func (b *circuitBreaker) reset(state breakerState, now time.Time) {
+	if state != b.state {
+		b.gen++
+	}
+	b.state = state
+	b.windowStart = now
+	b.requests = 0
+	b.failures = 0
+	if state == breakerOpen {
+		b.openedAt = now
+	}
+}
+
+// snapshot 返回当前状态和窗口内的请求数、失败数
+func (b *circuitBreaker) snapshot() (state breakerState, requests, failures int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state, b.requests, b.failures
+}
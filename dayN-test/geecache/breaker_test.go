@@ -0,0 +1,240 @@
+package geecache
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// roundTripFunc 把函数适配为 http.RoundTripper，用于伪造远程节点
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// fakeTransport 返回一个可以切换为"宕机"的 RoundTripper，并统计真正发出的请求数
+func fakeTransport(down *atomic.Bool, calls *int32) http.RoundTripper {
+	return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(calls, 1)
+		if down.Load() {
+			return nil, errors.New("connection refused")
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Body:       io.NopCloser(strings.NewReader("v")),
+			Request:    req,
+		}, nil
+	})
+}
+
+// allowed 调用 b.allow，只返回是否放行
+func allowed(b *circuitBreaker) bool {
+	_, ok := b.allow()
+	return ok
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newCircuitBreaker(CircuitBreakerOptions{Window: time.Minute, MinRequests: 4, FailureRate: 0.5, Cooldown: time.Second})
+	b.now = func() time.Time { return now }
+
+	// 请求数不足 MinRequests 时不熔断
+	for i := 0; i < 3; i++ {
+		b.record(b.gen, false)
+	}
+	if state, _, _ := b.snapshot(); state != breakerClosed {
+		t.Fatalf("expect closed below MinRequests, got %s", state)
+	}
+	b.record(b.gen, true)
+	if state, _, _ := b.snapshot(); state != breakerOpen || allowed(b) || b.ready() {
+		t.Fatalf("expect open after 3/4 failures, got %s", state)
+	}
+
+	// 冷却结束后只放行一个探测请求，探测失败重新熔断
+	now = now.Add(time.Second)
+	if !b.ready() || !allowed(b) || allowed(b) {
+		t.Fatal("expect exactly one probe after cooldown")
+	}
+	b.record(b.gen, false)
+	if state, _, _ := b.snapshot(); state != breakerOpen {
+		t.Fatalf("expect open after failed probe, got %s", state)
+	}
+
+	// 探测成功则恢复
+	now = now.Add(time.Second)
+	if !allowed(b) {
+		t.Fatal("expect probe after second cooldown")
+	}
+	b.record(b.gen, true)
+	if state, _, _ := b.snapshot(); state != breakerClosed || !allowed(b) {
+		t.Fatalf("expect closed after successful probe, got %s", state)
+	}
+}
+
+// TestCircuitBreakerWindow 窗口过期后重新统计，旧的失败不再计入
+func TestCircuitBreakerWindow(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newCircuitBreaker(CircuitBreakerOptions{Window: time.Second, MinRequests: 2, FailureRate: 1, Cooldown: time.Second})
+	b.now = func() time.Time { return now }
+
+	b.record(b.gen, false)
+	now = now.Add(2 * time.Second)
+	b.record(b.gen, false)
+	if state, requests, failures := b.snapshot(); state != breakerClosed || requests != 1 || failures != 1 {
+		t.Fatalf("expect a fresh window, got %s %d/%d", state, failures, requests)
+	}
+}
+
+// TestCircuitBreakerStaleResult 熔断前发出的请求在 half-open 期间才返回，不被当作探测结果
+func TestCircuitBreakerStaleResult(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newCircuitBreaker(CircuitBreakerOptions{Window: time.Minute, MinRequests: 2, FailureRate: 1, Cooldown: time.Second})
+	b.now = func() time.Time { return now }
+
+	slow, _ := b.allow()
+	b.record(b.gen, false)
+	b.record(b.gen, false)
+	now = now.Add(time.Second)
+	probe, ok := b.allow()
+	if !ok {
+		t.Fatal("expect a probe after cooldown")
+	}
+
+	b.record(slow, true)
+	b.abort(slow)
+	if state, _, _ := b.snapshot(); state != breakerHalfOpen || allowed(b) {
+		t.Fatalf("stale result should not end the probe, got %s", state)
+	}
+	b.record(probe, false)
+	if state, _, _ := b.snapshot(); state != breakerOpen {
+		t.Fatalf("expect open after failed probe, got %s", state)
+	}
+}
+
+// TestHTTPGetterBreaker 远程节点连续失败后熔断，不再发出请求；
+// PickPeer 跳过该节点，冷却后探测成功则恢复
+func TestHTTPGetterBreaker(t *testing.T) {
+	pool := NewHTTPPool("http://self")
+	pool.SetCircuitBreaker(&CircuitBreakerOptions{Window: time.Minute, MinRequests: 2, FailureRate: 0.5, Cooldown: time.Second})
+	pool.Set("http://a", "http://b", "http://c")
+
+	owner := pool.peers.Get("Tom")
+	h := pool.httpGetters[owner]
+	now := time.Unix(0, 0)
+	h.breaker.now = func() time.Time { return now }
+
+	var down atomic.Bool
+	var calls int32
+	down.Store(true)
+	h.transport = fakeTransport(&down, &calls)
+
+	for i := 0; i < 2; i++ {
+		if _, err := h.Get("scores", "Tom"); err == nil {
+			t.Fatal("expect error from a down peer")
+		}
+	}
+	if _, err := h.Get("scores", "Tom"); !errors.Is(err, errCircuitOpen) {
+		t.Fatalf("expect errCircuitOpen, got %v", err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Fatalf("open breaker should not send requests, sent %d", n)
+	}
+	if st := pool.PeerStats()[owner]; st.State != "open" {
+		t.Fatalf("expect open state in stats, got %+v", st)
+	}
+	if peer, ok := pool.PickPeer("Tom"); ok && peer == h {
+		t.Fatalf("PickPeer should skip open peer %s", owner)
+	}
+	for _, peer := range pool.PickPeers("Tom") {
+		if peer == h {
+			t.Fatalf("PickPeers should skip open peer %s", owner)
+		}
+	}
+
+	// 冷却结束，探测请求成功后恢复
+	down.Store(false)
+	now = now.Add(time.Second)
+	if peer, ok := pool.PickPeer("Tom"); !ok || peer != h {
+		t.Fatalf("expect owner %s to be picked for probing", owner)
+	}
+	if v, err := h.Get("scores", "Tom"); err != nil || string(v) != "v" {
+		t.Fatalf("probe failed: %q %v", v, err)
+	}
+	if st := pool.PeerStats()[owner]; st.State != "closed" {
+		t.Fatalf("expect closed state after probe, got %+v", st)
+	}
+}
+
+// TestHTTPGetterBreakerIgnoresCaller 调用方取消的请求和 4xx 不算节点故障
+func TestHTTPGetterBreakerIgnoresCaller(t *testing.T) {
+	h := &httpGetter{
+		baseURL: "http://a" + defaultBasePath,
+		breaker: newCircuitBreaker(CircuitBreakerOptions{Window: time.Minute, MinRequests: 1, FailureRate: 1, Cooldown: time.Minute}),
+		transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			if err := req.Context().Err(); err != nil {
+				return nil, err
+			}
+			return &http.Response{
+				StatusCode: http.StatusNotFound,
+				Status:     "404 Not Found",
+				Body:       io.NopCloser(strings.NewReader("")),
+				Request:    req,
+			}, nil
+		}),
+	}
+
+	if _, err := h.Get("scores", "Tom"); err == nil {
+		t.Fatal("expect error for 404")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := h.GetContext(ctx, "scores", "Tom"); err == nil {
+		t.Fatal("expect error for canceled ctx")
+	}
+	if state, _, failures := h.breaker.snapshot(); state != breakerClosed || failures != 0 {
+		t.Fatalf("expect no failures recorded, got %s with %d failures", state, failures)
+	}
+}
+
+// TestHTTPGetterBreakerIgnoresLoadErrors 远程 Getter 的加载失败（500）不算节点故障，503 才算
+func TestHTTPGetterBreakerIgnoresLoadErrors(t *testing.T) {
+	var code int32 = http.StatusInternalServerError
+	h := &httpGetter{
+		baseURL: "http://a" + defaultBasePath,
+		breaker: newCircuitBreaker(CircuitBreakerOptions{Window: time.Minute, MinRequests: 2, FailureRate: 0.5, Cooldown: time.Minute}),
+		transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			res := &http.Response{
+				StatusCode: int(atomic.LoadInt32(&code)),
+				Status:     http.StatusText(int(atomic.LoadInt32(&code))),
+				Header:     make(http.Header),
+				Body:       io.NopCloser(strings.NewReader("db is down\n")),
+				Request:    req,
+			}
+			if res.StatusCode == http.StatusInternalServerError {
+				res.Header.Set(loadErrorHeader, "1")
+			}
+			return res, nil
+		}),
+	}
+
+	if _, err := h.Get("scores", "Tom"); !errors.Is(err, ErrRemoteLoad) || err.Error() != "db is down" {
+		t.Fatalf("expect ErrRemoteLoad, got %v", err)
+	}
+	if state, _, failures := h.breaker.snapshot(); state != breakerClosed || failures != 0 {
+		t.Fatalf("load error should not count, got %s with %d failures", state, failures)
+	}
+	atomic.StoreInt32(&code, http.StatusServiceUnavailable)
+	if _, err := h.Get("scores", "Tom"); err == nil || errors.Is(err, ErrRemoteLoad) {
+		t.Fatalf("expect a status error for 503, got %v", err)
+	}
+	if state, _, _ := h.breaker.snapshot(); state != breakerOpen {
+		t.Fatalf("expect open after 503, got %s", state)
+	}
+}
@@ -1,8 +1,12 @@
-// 相比day4无变化
 package geecache
 
+import "time"
+
 type ByteView struct {
 	b []byte
+	// expire 是值写入缓存时确定的过期时间，零值表示不过期；
+	// Group.GetWithTTL 据此算出剩余有效期交给远程节点
+	expire time.Time
 }
 
 func (v ByteView) Len() int {
@@ -1,38 +1,256 @@
-// 相比day4无变化
 package geecache
 
 import (
 	"geecache/lru"
+	"geecache/tinylfu"
 	"sync"
+	"time"
 )
 
+// cache 是并发安全的缓存，按 key 的哈希分成若干个独立加锁的分片，
+// 容量平均分给各分片，减少多核下对同一把锁的争用
 type cache struct {
-	mu         sync.Mutex
-	lru        *lru.Cache
 	cacheBytes int64
+	nshards    int           // 分片数，0 视为 1
+	policy     lru.Policy    // 淘汰策略，nil 时使用 LRU
+	admission  bool          // 是否在淘汰策略前加上 W-TinyLFU 准入
+	ttl        time.Duration // 默认过期时间，0 表示永不过期
+	// now 返回当前时间，为 nil 时使用 time.Now；测试中可替换为假时钟，
+	// 它会通过 lru.Clocked 传给每个分片的 Store
+	now func() time.Time
+	// ticks 返回后台清理的定时器和停止它的函数，为 nil 时使用 time.NewTicker；测试中可替换为手动触发
+	ticks func(d time.Duration) (<-chan time.Time, func())
+
+	initOnce  sync.Once
+	shards    []*cacheShard
+	sweepOnce sync.Once
+	stopOnce  sync.Once
+	stop      chan struct{} // 关闭后后台清理 goroutine 退出
+}
+
+// cacheShard 是一个分片，由自己的 mu 保护
+type cacheShard struct {
+	mu    sync.Mutex
+	store lru.Store
+
+	// 以下统计量均在 mu 保护下更新
+	nget, nhit, nevict int64
+	// reasons 表示 store 通过 lru.ReasonNotifier 报告淘汰原因，此时只统计容量淘汰
+	reasons bool
+}
+
+// CacheStats 是某一个缓存（mainCache 或 hotCache）的统计快照
+type CacheStats struct {
+	Bytes     int64 `json:"bytes"`
+	Items     int64 `json:"items"`
+	Gets      int64 `json:"gets"`
+	Hits      int64 `json:"hits"`
+	Evictions int64 `json:"evictions"`
+}
+
+// add 写入 key，返回带上过期时间的 value
+func (c *cache) add(key string, value ByteView) ByteView {
+	return c.addWithTTL(key, value, 0)
+}
+
+// addWithTTL 与 add 相同，但 ttl > 0 时记录的有效期不超过 ttl（同时仍受 c.ttl 限制）
+func (c *cache) addWithTTL(key string, value ByteView, ttl time.Duration) ByteView {
+	if ttl <= 0 || (c.ttl > 0 && c.ttl < ttl) {
+		ttl = c.ttl
+	}
+	value.expire = time.Time{}
+	if ttl > 0 {
+		value.expire = c.clock().Add(ttl)
+	}
+	s := c.shard(key)
+	s.mu.Lock()
+	if s.store == nil {
+		policy := c.policy
+		if policy == nil {
+			policy = lru.NewStore
+		}
+		if c.admission {
+			policy = tinylfu.Wrap(policy)
+		}
+		s.store = policy(c.shardBytes(), func(string, lru.Value) {
+			if !s.reasons {
+				s.nevict++
+			}
+		})
+		// 过期不算淘汰，能区分原因的 Store 只统计容量淘汰
+		if rn, ok := s.store.(lru.ReasonNotifier); ok {
+			s.reasons = true
+			rn.SetOnEvictedWithReason(func(_ string, _ lru.Value, reason lru.EvictReason) {
+				if reason == lru.EvictCapacity {
+					s.nevict++
+				}
+			})
+		}
+		if cl, ok := s.store.(lru.Clocked); ok && c.now != nil {
+			cl.SetNow(c.now)
+		}
+	}
+	s.store.AddWithTTL(key, value, ttl)
+	s.mu.Unlock()
+
+	if c.ttl > 0 {
+		c.startSweeper()
+	}
+	return value
 }
 
-func (c *cache) add(key string, value ByteView) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+// remove 删除 key，不计入淘汰次数
+func (c *cache) remove(key string) {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	if c.lru == nil {
-		c.lru = lru.New(c.cacheBytes, nil)
+	if s.store != nil {
+		s.store.Remove(key)
 	}
-	c.lru.Add(key, value)
 }
 
 func (c *cache) get(key string) (value ByteView, ok bool) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	if c.lru == nil {
+	s.nget++
+	if s.store == nil {
 		return
 	}
 
-	if v, ok := c.lru.Get(key); ok {
+	if v, ok := s.store.Get(key); ok {
+		s.nhit++
 		return v.(ByteView), ok
 	}
 
 	return
 }
+
+// contains 判断 key 是否在缓存中，不计入 Gets/Hits，也不改变淘汰顺序
+func (c *cache) contains(key string) bool {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.store != nil && s.store.Contains(key)
+}
+
+// allShards 返回所有分片，第一次调用时创建
+func (c *cache) allShards() []*cacheShard {
+	c.initOnce.Do(func() {
+		c.shards = make([]*cacheShard, max(c.nshards, 1))
+		for i := range c.shards {
+			c.shards[i] = &cacheShard{}
+		}
+	})
+	return c.shards
+}
+
+// shard 返回 key 所在的分片
+func (c *cache) shard(key string) *cacheShard {
+	shards := c.allShards()
+	if len(shards) == 1 {
+		return shards[0]
+	}
+	return shards[fnv32a(key)%uint32(len(shards))]
+}
+
+// shardBytes 每个分片的容量；cacheBytes 为 0 表示不限容量
+func (c *cache) shardBytes() int64 {
+	if c.cacheBytes == 0 {
+		return 0
+	}
+	return max(c.cacheBytes/int64(len(c.shards)), 1)
+}
+
+func (c *cache) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+// startSweeper 在第一次写入时启动后台清理，之后每隔 ttl 清理一次过期记录。
+// Get 只会惰性删除被访问到的过期记录，过期后再没被访问的记录靠它回收内存，
+// 即使 Group 之后不再有任何写入
+func (c *cache) startSweeper() {
+	c.sweepOnce.Do(func() {
+		c.stop = make(chan struct{})
+		ticks := c.ticks
+		if ticks == nil {
+			ticks = func(d time.Duration) (<-chan time.Time, func()) {
+				t := time.NewTicker(d)
+				return t.C, t.Stop
+			}
+		}
+		tick, stopTick := ticks(c.ttl)
+		go c.sweep(tick, stopTick, c.stop)
+	})
+}
+
+// sweep 每收到一次 tick 清理一次过期记录，直到 stop 被关闭
+func (c *cache) sweep(tick <-chan time.Time, stopTick func(), stop <-chan struct{}) {
+	defer stopTick()
+	for {
+		select {
+		case <-tick:
+			c.removeExpired()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// close 停止后台清理；还未启动时阻止它以后再启动
+func (c *cache) close() {
+	c.sweepOnce.Do(func() {})
+	c.stopOnce.Do(func() {
+		if c.stop != nil {
+			close(c.stop)
+		}
+	})
+}
+
+func (c *cache) removeExpired() int64 {
+	var freed int64
+	for _, s := range c.allShards() {
+		s.mu.Lock()
+		if s.store != nil {
+			freed += s.store.RemoveExpired()
+		}
+		s.mu.Unlock()
+	}
+	return freed
+}
+
+// stats 汇总所有分片的统计信息
+func (c *cache) stats() CacheStats {
+	var st CacheStats
+	for _, s := range c.allShards() {
+		s.mu.Lock()
+		st.Gets += s.nget
+		st.Hits += s.nhit
+		st.Evictions += s.nevict
+		if s.store != nil {
+			st.Bytes += s.store.Bytes()
+			st.Items += int64(s.store.Len())
+		}
+		s.mu.Unlock()
+	}
+	return st
+}
+
+// fnv32a 计算 key 的 32 位 FNV-1a 哈希，不产生内存分配
+func fnv32a(key string) uint32 {
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+	h := uint32(offset32)
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= prime32
+	}
+	return h
+}
@@ -0,0 +1,54 @@
+package geecache
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestCacheShards(t *testing.T) {
+	c := &cache{cacheBytes: 1 << 10, nshards: 4}
+	for i := 0; i < 100; i++ {
+		k := strconv.Itoa(i)
+		c.add(k, ByteView{b: []byte(k)})
+	}
+	for i := 0; i < 100; i++ {
+		k := strconv.Itoa(i)
+		if v, ok := c.get(k); !ok || v.String() != k {
+			t.Fatalf("cache miss %s", k)
+		}
+	}
+
+	if len(c.shards) != 4 {
+		t.Fatalf("expect 4 shards, got %d", len(c.shards))
+	}
+	for i, s := range c.shards {
+		if s.store == nil || s.store.Len() == 0 {
+			t.Fatalf("shard %d is empty, keys are not spread", i)
+		}
+	}
+	if st := c.stats(); st.Items != 100 || st.Gets != 100 || st.Hits != 100 {
+		t.Fatalf("unexpected aggregated stats %+v", st)
+	}
+}
+
+// benchmarkCacheGet 并行读取已缓存的 key，比较不同分片数下的吞吐
+func benchmarkCacheGet(b *testing.B, shards int) {
+	c := &cache{cacheBytes: 1 << 20, nshards: shards}
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+		c.add(keys[i], ByteView{b: []byte(keys[i])})
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			c.get(keys[i%len(keys)])
+			i++
+		}
+	})
+}
+
+func BenchmarkCacheGet1Shard(b *testing.B)   { benchmarkCacheGet(b, 1) }
+func BenchmarkCacheGet16Shards(b *testing.B) { benchmarkCacheGet(b, 16) }
+func BenchmarkCacheGet64Shards(b *testing.B) { benchmarkCacheGet(b, 64) }
@@ -1,8 +1,9 @@
-// 相比day4无变化
 package consistenthash
 
 import (
 	"hash/crc32"
+	"math"
+	"slices"
 	"sort"
 	"strconv"
 )
@@ -14,6 +15,7 @@ type Map struct {
 	replicas int
 	keys     []int // Sorted
 	hashMap  map[int]string
+	members  map[string]int // 真实节点 -> 虚拟节点个数
 }
 
 func New(replicas int, fn Hash) *Map {
@@ -21,6 +23,7 @@ func New(replicas int, fn Hash) *Map {
 		replicas: replicas,
 		hash:     fn,
 		hashMap:  make(map[int]string),
+		members:  make(map[string]int),
 	}
 	if m.hash == nil {
 		m.hash = crc32.ChecksumIEEE
@@ -28,28 +31,141 @@ func New(replicas int, fn Hash) *Map {
 	return m
 }
 
+// Add 添加真实节点，每个节点生成 replicas 个虚拟节点；已存在的节点会被忽略
 func (m *Map) Add(keys ...string) {
 	for _, key := range keys {
-		for i := 0; i < m.replicas; i++ {
+		m.addNode(key, m.replicas)
+	}
+	sort.Ints(m.keys)
+}
+
+// AddWeighted 添加一个带权重的真实节点，虚拟节点个数为 replicas*weight，
+// 因此分到的 key 数量大致与 weight 成正比（例如按内存大小设置权重）。
+// weight <= 0 或节点已存在时忽略
+func (m *Map) AddWeighted(key string, weight int) {
+	if weight <= 0 {
+		return
+	}
+	m.addNode(key, m.replicas*weight)
+	sort.Ints(m.keys)
+}
+
+// addNode 为 key 生成 n 个虚拟节点，调用方负责排序 m.keys
+func (m *Map) addNode(key string, n int) {
+	if _, ok := m.members[key]; ok {
+		return
+	}
+	for i := 0; i < n; i++ {
+		hash := int(m.hash([]byte(strconv.Itoa(i) + key)))
+		m.keys = append(m.keys, hash)
+		m.hashMap[hash] = key
+	}
+	m.members[key] = n
+}
+
+// Remove 删除真实节点及其所有虚拟节点，不存在的节点会被忽略。
+// 只有原本落在这些节点上的 key 会被重新分配
+func (m *Map) Remove(keys ...string) {
+	removed := false
+	for _, key := range keys {
+		n, ok := m.members[key]
+		if !ok {
+			continue
+		}
+		for i := 0; i < n; i++ {
 			hash := int(m.hash([]byte(strconv.Itoa(i) + key)))
-			m.keys = append(m.keys, hash)
-			m.hashMap[hash] = key
+			// 虚拟节点哈希冲突时 hashMap 中可能是别的节点，不能误删
+			if m.hashMap[hash] == key {
+				delete(m.hashMap, hash)
+			}
 		}
+		delete(m.members, key)
+		removed = true
+	}
+	if !removed {
+		return
+	}
+	m.keys = m.keys[:0]
+	for hash := range m.hashMap {
+		m.keys = append(m.keys, hash)
 	}
 	sort.Ints(m.keys)
 }
 
+// Members 返回哈希环上所有真实节点，按字典序排列
+func (m *Map) Members() []string {
+	nodes := make([]string, 0, len(m.members))
+	for node := range m.members {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
 func (m *Map) Get(key string) string {
 	if len(m.keys) == 0 {
 		return ""
 	}
 
+	idx := m.search(key)
+	return m.hashMap[m.keys[idx]]
+}
+
+// GetN 从 key 在环上的位置顺时针查找，返回最多 n 个互不相同的真实节点，
+// 第一个就是 Get 的结果，其后依次是"下一任"负责节点，用于副本和故障转移
+func (m *Map) GetN(key string, n int) []string {
+	if len(m.keys) == 0 || n <= 0 {
+		return nil
+	}
+	n = min(n, len(m.members))
+	nodes := make([]string, 0, n)
+	idx := m.search(key)
+	for i := 0; i < len(m.keys) && len(nodes) < n; i++ {
+		node := m.hashMap[m.keys[(idx+i)%len(m.keys)]]
+		if !slices.Contains(nodes, node) {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// GetBounded 实现"有界负载的一致性哈希"（consistent hashing with bounded loads）：
+// 每个节点的负载上限为 ceil((1+epsilon) * (总负载+1) / 节点数)，
+// 从 key 在环上的位置顺时针查找，返回第一个加上本次请求后不超过上限的节点。
+// load 返回节点当前的负载（如进行中的请求数）。epsilon 越小越均衡，但 key 迁移越多
+func (m *Map) GetBounded(key string, epsilon float64, load func(node string) int64) string {
+	if len(m.keys) == 0 {
+		return ""
+	}
+
+	var total int64
+	for node := range m.members {
+		total += load(node)
+	}
+	limit := int64(math.Ceil((1 + epsilon) * float64(total+1) / float64(len(m.members))))
+
+	idx := m.search(key)
+	seen := make(map[string]bool, len(m.members))
+	for i := 0; i < len(m.keys) && len(seen) < len(m.members); i++ {
+		node := m.hashMap[m.keys[(idx+i)%len(m.keys)]]
+		if seen[node] {
+			continue
+		}
+		seen[node] = true
+		if load(node)+1 <= limit {
+			return node
+		}
+	}
+	// 总有节点低于平均负载，正常不会走到这里
+	return m.hashMap[m.keys[idx]]
+}
+
+// search 返回 key 顺时针方向第一个虚拟节点在 m.keys 中的下标
+func (m *Map) search(key string) int {
 	hash := int(m.hash([]byte(key)))
 	// Binary search for appropriate replica.
 	idx := sort.Search(len(m.keys), func(i int) bool {
 		return m.keys[i] >= hash
 	})
-
-	realIdx := idx % len(m.keys)
-	return m.hashMap[m.keys[realIdx]]
+	return idx % len(m.keys)
 }
@@ -0,0 +1,183 @@
+package consistenthash
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// TestHashing 测试一致性哈希在添加节点前后，对不同 key 的映射是否符合预期
+func TestHashing(t *testing.T) {
+	// 哈希函数直接把传入的字节（ASCII 数字）转成对应的整数值
+	hash := New(3, func(key []byte) uint32 {
+		i, _ := strconv.Atoi(string(key))
+		return uint32(i)
+	})
+
+	// 虚拟节点：“6” → 6,16,26； “4” → 4,14,24； “2” → 2,12,22
+	hash.Add("6", "4", "2")
+
+	testCases := map[string]string{
+		"2":  "2",
+		"11": "2",
+		"23": "4",
+		"27": "2",
+	}
+
+	for k, v := range testCases {
+		if hash.Get(k) != v {
+			t.Errorf("Asking for %s, should have yielded %s", k, v)
+		}
+	}
+
+	// 添加 "8"，生成虚拟节点 8,18,28
+	hash.Add("8")
+
+	// 27 的顺时针第一个 ≥27 的位置现在是 28→"8"
+	testCases["27"] = "8"
+
+	for k, v := range testCases {
+		if hash.Get(k) != v {
+			t.Errorf("Asking for %s, should have yielded %s", k, v)
+		}
+	}
+}
+
+// TestRemove 删除节点后，只有原本落在该节点上的 key 被重新分配
+func TestRemove(t *testing.T) {
+	hash := New(3, func(key []byte) uint32 {
+		i, _ := strconv.Atoi(string(key))
+		return uint32(i)
+	})
+	hash.Add("6", "4", "2", "8")
+	hash.Add("8") // 重复添加被忽略
+
+	if members := hash.Members(); !reflect.DeepEqual(members, []string{"2", "4", "6", "8"}) {
+		t.Fatalf("unexpected members %v", members)
+	}
+	if len(hash.keys) != 12 {
+		t.Fatalf("expect 12 virtual nodes, got %d", len(hash.keys))
+	}
+
+	// 删除 "4"：虚拟节点 4,14,24 消失
+	hash.Remove("4", "unknown")
+	testCases := map[string]string{
+		"2":  "2",
+		"3":  "6", // 原来是 4
+		"11": "2",
+		"23": "6", // 原来是 24→"4"，现在是 26→"6"
+		"27": "8",
+	}
+	for k, v := range testCases {
+		if hash.Get(k) != v {
+			t.Errorf("Asking for %s, should have yielded %s", k, v)
+		}
+	}
+	if members := hash.Members(); !reflect.DeepEqual(members, []string{"2", "6", "8"}) {
+		t.Fatalf("unexpected members %v", members)
+	}
+
+	hash.Remove("2", "6", "8")
+	if hash.Get("1") != "" {
+		t.Fatalf("empty ring should yield empty string")
+	}
+}
+
+// sha1Hash 取 SHA-1 的前 4 字节。默认的 CRC32 对只差几个字符的节点名（如相邻端口）
+// 分布很不均匀，测量分布时使用混合更充分的哈希
+func sha1Hash(data []byte) uint32 {
+	sum := sha1.Sum(data)
+	return binary.BigEndian.Uint32(sum[:4])
+}
+
+// TestAddWeighted 各节点分到的 key 比例应接近其权重比例
+func TestAddWeighted(t *testing.T) {
+	hash := New(50, sha1Hash)
+	weights := map[string]int{
+		"http://localhost:8001": 1,
+		"http://localhost:8002": 2,
+		"http://localhost:8003": 4,
+	}
+	total := 0
+	for node, w := range weights {
+		hash.AddWeighted(node, w)
+		total += w
+	}
+	if len(hash.keys) != 50*total {
+		t.Fatalf("expect %d virtual nodes, got %d", 50*total, len(hash.keys))
+	}
+
+	const n = 100000
+	counts := make(map[string]int)
+	for i := 0; i < n; i++ {
+		counts[hash.Get("key"+strconv.Itoa(i))]++
+	}
+
+	const tolerance = 0.1 // 允许与期望比例有 10% 的相对偏差
+	for node, w := range weights {
+		expect := float64(n) * float64(w) / float64(total)
+		got := float64(counts[node])
+		if got < expect*(1-tolerance) || got > expect*(1+tolerance) {
+			t.Errorf("node %s (weight %d) got %.0f keys, expect about %.0f", node, w, got, expect)
+		}
+	}
+}
+
+func TestGetBounded(t *testing.T) {
+	hash := New(3, func(key []byte) uint32 {
+		i, _ := strconv.Atoi(string(key))
+		return uint32(i)
+	})
+	hash.Add("6", "4", "2")
+
+	loads := map[string]int64{}
+	load := func(node string) int64 { return loads[node] }
+
+	// 负载均为 0 时与 Get 一致
+	if node := hash.GetBounded("11", 0.25, load); node != "2" {
+		t.Fatalf("expect 2, got %s", node)
+	}
+
+	// 总负载 6，上限 ceil(1.25*7/3)=3；"2" 已有 4 个请求，跳到顺时针下一个节点 "4"
+	loads["2"] = 4
+	loads["6"] = 2
+	if node := hash.GetBounded("11", 0.25, load); node != "4" {
+		t.Fatalf("expect 4, got %s", node)
+	}
+	// 总负载 8，上限 ceil(1.25*9/3)=4；"4" 也满了，继续跳到 "6"
+	loads["4"] = 4
+	loads["6"] = 0
+	if node := hash.GetBounded("11", 0.25, load); node != "6" {
+		t.Fatalf("expect 6, got %s", node)
+	}
+}
+
+func TestGetN(t *testing.T) {
+	hash := New(3, func(key []byte) uint32 {
+		i, _ := strconv.Atoi(string(key))
+		return uint32(i)
+	})
+	// 虚拟节点排序后：2,4,6,12,14,16,22,24,26
+	hash.Add("6", "4", "2")
+
+	testCases := []struct {
+		key    string
+		n      int
+		expect []string
+	}{
+		{"11", 1, []string{"2"}},
+		{"11", 2, []string{"2", "4"}},
+		{"23", 3, []string{"4", "6", "2"}},
+		{"27", 5, []string{"2", "4", "6"}}, // 最多返回全部真实节点
+	}
+	for _, tc := range testCases {
+		if got := hash.GetN(tc.key, tc.n); !reflect.DeepEqual(got, tc.expect) {
+			t.Errorf("GetN(%s, %d) = %v, want %v", tc.key, tc.n, got, tc.expect)
+		}
+	}
+	if got := hash.GetN("11", 2)[0]; got != hash.Get("11") {
+		t.Errorf("first of GetN should equal Get, got %s", got)
+	}
+}
@@ -0,0 +1,85 @@
+package consistenthash
+
+import "sort"
+
+// Jump 实现 Lamping 和 Veach 的跳跃一致性哈希（jump consistent hash）：
+// 不占额外内存，O(ln n) 计算出 key 所在的桶，分布非常均匀。
+// 桶号是节点按字典序排列后的下标，与加入顺序无关，各节点据此得到相同的结果。
+// 它只能在末尾增删桶：只有增删字典序最大的节点时迁移的 key 最少；
+// 增删排在中间的节点会让其后所有节点的桶号移动，导致大量的 key 迁移
+type Jump struct {
+	nodes []string // 下标即桶号，按字典序排列
+}
+
+func NewJump() *Jump {
+	return &Jump{}
+}
+
+// Add 按字典序插入节点；已存在的节点会被忽略
+func (j *Jump) Add(nodes ...string) {
+	for _, node := range nodes {
+		i, ok := j.index(node)
+		if ok {
+			continue
+		}
+		j.nodes = append(j.nodes, "")
+		copy(j.nodes[i+1:], j.nodes[i:])
+		j.nodes[i] = node
+	}
+}
+
+// Remove 删除节点，其后的节点依次前移
+func (j *Jump) Remove(nodes ...string) {
+	for _, node := range nodes {
+		if i, ok := j.index(node); ok {
+			j.nodes = append(j.nodes[:i], j.nodes[i+1:]...)
+		}
+	}
+}
+
+// Members 返回所有节点，按字典序排列
+func (j *Jump) Members() []string {
+	return append([]string(nil), j.nodes...)
+}
+
+func (j *Jump) Get(key string) string {
+	if len(j.nodes) == 0 {
+		return ""
+	}
+	return j.nodes[jumpHash(mix64(hash64(key)), len(j.nodes))]
+}
+
+// GetN 返回最多 n 个不同的节点，第一个与 Get 相同：
+// 每次从剩余节点中再做一次跳跃哈希，选出的节点从候选中删除。
+// 负责节点恰好是末尾的节点时，第二个节点就是删除它之后 Get 的结果
+func (j *Jump) GetN(key string, n int) []string {
+	if len(j.nodes) == 0 || n <= 0 {
+		return nil
+	}
+	h := mix64(hash64(key))
+	rest := append([]string(nil), j.nodes...)
+	names := make([]string, 0, min(n, len(rest)))
+	for len(names) < n && len(rest) > 0 {
+		i := jumpHash(h, len(rest))
+		names = append(names, rest[i])
+		rest = append(rest[:i], rest[i+1:]...)
+	}
+	return names
+}
+
+// index 返回 node 在 j.nodes 中的位置，不存在时返回应插入的位置和 false
+func (j *Jump) index(node string) (int, bool) {
+	i := sort.SearchStrings(j.nodes, node)
+	return i, i < len(j.nodes) && j.nodes[i] == node
+}
+
+// jumpHash 把 key 映射到 [0, buckets) 中的一个桶
+func jumpHash(key uint64, buckets int) int {
+	var b, j int64 = -1, 0
+	for j < int64(buckets) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
@@ -0,0 +1,146 @@
+package consistenthash
+
+import "sort"
+
+// DefaultMaglevSize 是 Maglev 查找表的默认大小，必须是质数，且应远大于节点数（论文建议 M > 100*N）
+const DefaultMaglevSize = 65537
+
+// Maglev 实现 Google Maglev 论文中的一致性哈希：
+// 每个节点按自己的排列（offset + j*skip）轮流抢占查找表中的槽位，
+// 最终每个节点占据的槽位数几乎完全相同。查找只需一次取模和一次数组访问，O(1)。
+// 节点变更时整表重建，少量 key 会在原有节点之间迁移（不是严格的最小迁移）
+type Maglev struct {
+	size  uint64
+	nodes []string // 按字典序排列，保证各节点上的查找表一致
+	table []int    // 槽位 -> nodes 下标
+}
+
+// NewMaglev 创建查找表大小为 size 的 Maglev；size 不是质数时向上取最近的质数，
+// size <= 0 时使用 DefaultMaglevSize
+func NewMaglev(size int) *Maglev {
+	if size <= 0 {
+		size = DefaultMaglevSize
+	}
+	return &Maglev{size: uint64(nextPrime(size))}
+}
+
+var _ ReplicaPicker = (*Maglev)(nil)
+
+// Add 添加节点并重建查找表；已存在的节点会被忽略
+func (m *Maglev) Add(nodes ...string) {
+	changed := false
+	for _, node := range nodes {
+		if i := sort.SearchStrings(m.nodes, node); i < len(m.nodes) && m.nodes[i] == node {
+			continue
+		}
+		m.nodes = append(m.nodes, node)
+		sort.Strings(m.nodes)
+		changed = true
+	}
+	if changed {
+		m.populate()
+	}
+}
+
+// Remove 删除节点并重建查找表
+func (m *Maglev) Remove(nodes ...string) {
+	changed := false
+	for _, node := range nodes {
+		if i := sort.SearchStrings(m.nodes, node); i < len(m.nodes) && m.nodes[i] == node {
+			m.nodes = append(m.nodes[:i], m.nodes[i+1:]...)
+			changed = true
+		}
+	}
+	if changed {
+		m.populate()
+	}
+}
+
+// Members 返回所有节点，按字典序排列
+func (m *Maglev) Members() []string {
+	return append([]string(nil), m.nodes...)
+}
+
+func (m *Maglev) Get(key string) string {
+	if len(m.table) == 0 {
+		return ""
+	}
+	return m.nodes[m.table[mix64(hash64(key))%m.size]]
+}
+
+// GetN 从 key 所在的槽位开始顺序扫描查找表，返回最多 n 个不同的节点，第一个与 Get 相同
+func (m *Maglev) GetN(key string, n int) []string {
+	if len(m.table) == 0 || n <= 0 {
+		return nil
+	}
+	n = min(n, len(m.nodes))
+	names := make([]string, 0, n)
+	seen := make([]bool, len(m.nodes))
+	slot := mix64(hash64(key)) % m.size
+	for i := uint64(0); i < m.size && len(names) < n; i++ {
+		if idx := m.table[(slot+i)%m.size]; !seen[idx] {
+			seen[idx] = true
+			names = append(names, m.nodes[idx])
+		}
+	}
+	return names
+}
+
+// populate 按 Maglev 论文的算法重建查找表：
+// 节点 i 的排列为 permutation[i][j] = (offset + j*skip) % size，
+// 各节点轮流取自己排列中下一个空槽位，直到填满整张表
+func (m *Maglev) populate() {
+	n := len(m.nodes)
+	if n == 0 {
+		m.table = nil
+		return
+	}
+	offset := make([]uint64, n)
+	skip := make([]uint64, n)
+	next := make([]uint64, n)
+	for i, node := range m.nodes {
+		h := hash64(node)
+		offset[i] = h % m.size
+		skip[i] = mix64(h)%(m.size-1) + 1
+	}
+
+	table := make([]int, m.size)
+	for i := range table {
+		table[i] = -1
+	}
+	for filled := uint64(0); ; {
+		for i := 0; i < n; i++ {
+			c := (offset[i] + next[i]*skip[i]) % m.size
+			for table[c] >= 0 {
+				next[i]++
+				c = (offset[i] + next[i]*skip[i]) % m.size
+			}
+			table[c] = i
+			next[i]++
+			filled++
+			if filled == m.size {
+				m.table = table
+				return
+			}
+		}
+	}
+}
+
+// nextPrime 返回大于等于 n 的最小质数
+func nextPrime(n int) int {
+	if n <= 2 {
+		return 2
+	}
+	for ; ; n++ {
+		prime := true
+		for d := 2; d*d <= n; d++ {
+			if n%d == 0 {
+				prime = false
+				break
+			}
+		}
+		if prime {
+			return n
+		}
+	}
+}
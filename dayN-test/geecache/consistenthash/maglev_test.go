@@ -0,0 +1,50 @@
+package consistenthash
+
+import (
+	"strconv"
+	"testing"
+)
+
+// TestMaglevBalance 每个节点占据的槽位数与平均值相差不超过 1%
+func TestMaglevBalance(t *testing.T) {
+	m := NewMaglev(0)
+	m.Add(nodeNames(7)...)
+
+	counts := make(map[int]int)
+	for _, i := range m.table {
+		if i < 0 {
+			t.Fatal("table has empty slots")
+		}
+		counts[i]++
+	}
+	avg := float64(DefaultMaglevSize) / 7
+	for i, c := range counts {
+		if float64(c) < avg*0.99 || float64(c) > avg*1.01 {
+			t.Errorf("node %s owns %d slots, average %.0f", m.nodes[i], c, avg)
+		}
+	}
+}
+
+func TestNextPrime(t *testing.T) {
+	for n, want := range map[int]int{1: 2, 2: 2, 4: 5, 100: 101, 65536: 65537} {
+		if got := nextPrime(n); got != want {
+			t.Errorf("nextPrime(%d) = %d, want %d", n, got, want)
+		}
+	}
+}
+
+// TestMaglevGetN GetN 的第一个节点等于 Get，节点互不相同
+func TestMaglevGetN(t *testing.T) {
+	m := NewMaglev(0)
+	m.Add(nodeNames(5)...)
+	for i := 0; i < 1000; i++ {
+		key := "key" + strconv.Itoa(i)
+		got := m.GetN(key, 3)
+		if len(got) != 3 || got[0] != m.Get(key) || got[1] == got[0] || got[2] == got[0] || got[2] == got[1] {
+			t.Fatalf("GetN(%s) = %v", key, got)
+		}
+	}
+	if got := m.GetN("key", 10); len(got) != 5 {
+		t.Fatalf("expect all 5 nodes, got %v", got)
+	}
+}
@@ -0,0 +1,63 @@
+package consistenthash
+
+// Picker 是"key -> 真实节点"的放置策略。
+// Map（一致性哈希环）、Rendezvous（最高随机权重哈希）和 Jump（跳跃一致性哈希）都实现了它，
+// 调用方负责并发控制
+type Picker interface {
+	Add(nodes ...string)
+	Remove(nodes ...string)
+	Members() []string
+	Get(key string) string // 没有节点时返回 ""
+}
+
+// WeightedPicker 由支持按权重分配 key 的策略实现
+type WeightedPicker interface {
+	Picker
+	AddWeighted(node string, weight int)
+}
+
+// BoundedPicker 由支持有界负载查找的策略实现
+type BoundedPicker interface {
+	Picker
+	GetBounded(key string, epsilon float64, load func(node string) int64) string
+}
+
+// ReplicaPicker 由能按顺序给出多个候选节点的策略实现，
+// 第一个节点负责该 key，其后是它故障时依次接替的节点
+type ReplicaPicker interface {
+	Picker
+	GetN(key string, n int) []string
+}
+
+var (
+	_ WeightedPicker = (*Map)(nil)
+	_ ReplicaPicker  = (*Map)(nil)
+	_ ReplicaPicker  = (*Rendezvous)(nil)
+	_ BoundedPicker  = (*Map)(nil)
+	_ WeightedPicker = (*Rendezvous)(nil)
+	_ ReplicaPicker  = (*Jump)(nil)
+)
+
+// hash64 计算 64 位 FNV-1a 哈希
+func hash64(s string) uint64 {
+	const (
+		offset64 = 14695981039346656037
+		prime64  = 1099511628211
+	)
+	h := uint64(offset64)
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= prime64
+	}
+	return h
+}
+
+// mix64 是 splitmix64 的终结函数，把输入充分打散，弥补 FNV 低位混合不足
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
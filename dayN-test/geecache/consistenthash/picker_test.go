@@ -0,0 +1,194 @@
+package consistenthash
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+var pickers = []struct {
+	name     string
+	new      func() Picker
+	minimal  bool // 增删节点时是否只迁移涉及该节点的 key
+	tailOnly bool // 只有增删字典序最大的节点时才是最小迁移
+}{
+	{"ring", func() Picker { return New(50, nil) }, true, false},
+	{"rendezvous", func() Picker { return NewRendezvous() }, true, false},
+	{"jump", func() Picker { return NewJump() }, true, true},
+	{"maglev", func() Picker { return NewMaglev(0) }, false, false},
+}
+
+func nodeNames(n int) []string {
+	nodes := make([]string, n)
+	for i := range nodes {
+		nodes[i] = fmt.Sprintf("http://10.0.0.%d:8001", i)
+	}
+	return nodes
+}
+
+// owners 记录每个 key 当前的负责节点
+func owners(p Picker, keys int) []string {
+	o := make([]string, keys)
+	for i := range o {
+		o[i] = p.Get("key" + strconv.Itoa(i))
+	}
+	return o
+}
+
+// TestRemapOnAdd 加入第 n+1 个节点时，理想情况下只有约 1/(n+1) 的 key 迁移，且只迁移到新节点
+func TestRemapOnAdd(t *testing.T) {
+	const nodes, keys = 10, 20000
+	for _, tc := range pickers {
+		p := tc.new()
+		p.Add(nodeNames(nodes)...)
+		before := owners(p, keys)
+
+		// 新节点按字典序排在最后，Jump 只有这种情况是最小迁移
+		added := "http://10.0.1.0:8001"
+		p.Add(added)
+		after := owners(p, keys)
+
+		moved := 0
+		for i := range before {
+			if before[i] != after[i] {
+				moved++
+				if tc.minimal && after[i] != added {
+					t.Fatalf("%s: key%d moved from %s to %s, not to the new node", tc.name, i, before[i], after[i])
+				}
+			}
+		}
+		fraction := float64(moved) / keys
+		t.Logf("%s: %.2f%% keys remapped on add (ideal %.2f%%)", tc.name, fraction*100, 100.0/(nodes+1))
+		if fraction > 3.0/(nodes+1) {
+			t.Errorf("%s: too many keys remapped: %.2f%%", tc.name, fraction*100)
+		}
+	}
+}
+
+// TestRemapOnRemove 删除节点时，只有原本由它负责的 key 迁移。
+// Jump 只能无损删除字典序最大的节点，删除中间节点会迁移大量 key
+func TestRemapOnRemove(t *testing.T) {
+	const nodes, keys = 10, 20000
+	for _, tc := range pickers {
+		for _, pos := range []string{"last", "middle"} {
+			all := nodeNames(nodes)
+			p := tc.new()
+			p.Add(all...)
+			before := owners(p, keys)
+
+			removed := all[nodes-1]
+			if pos == "middle" {
+				removed = all[nodes/2]
+			}
+			p.Remove(removed)
+			after := owners(p, keys)
+
+			minimal := tc.minimal && (pos == "last" || !tc.tailOnly)
+			moved := 0
+			for i := range before {
+				if before[i] != after[i] {
+					moved++
+					if minimal && before[i] != removed {
+						t.Fatalf("%s: key%d moved from %s, which was not removed", tc.name, i, before[i])
+					}
+				}
+			}
+			fraction := float64(moved) / keys
+			t.Logf("%s: %.2f%% keys remapped on removing the %s node (ideal %.2f%%)", tc.name, fraction*100, pos, 100.0/nodes)
+			if tc.tailOnly && pos == "middle" && fraction < 3.0/nodes {
+				t.Errorf("%s: expect a middle removal to remap most keys, got %.2f%%", tc.name, fraction*100)
+			}
+		}
+	}
+}
+
+// TestJumpOrder 桶号与加入顺序无关，按不同顺序加入节点的 Jump 结果相同
+func TestJumpOrder(t *testing.T) {
+	all := nodeNames(10)
+	a, b := NewJump(), NewJump()
+	a.Add(all...)
+	for i := len(all) - 1; i >= 0; i-- {
+		b.Add(all[i])
+	}
+	if !reflect.DeepEqual(owners(a, 1000), owners(b, 1000)) {
+		t.Fatal("jump owners depend on insertion order")
+	}
+}
+
+// TestJumpGetN GetN 的第一个节点等于 Get，节点互不相同；
+// 删除末尾的负责节点后，Get 的结果就是原来 GetN 的第二个节点
+func TestJumpGetN(t *testing.T) {
+	all := nodeNames(5)
+	j := NewJump()
+	j.Add(all...)
+	for i := 0; i < 1000; i++ {
+		key := "key" + strconv.Itoa(i)
+		got := j.GetN(key, 10)
+		seen := map[string]bool{}
+		for _, n := range got {
+			seen[n] = true
+		}
+		if len(got) != len(all) || len(seen) != len(all) || got[0] != j.Get(key) {
+			t.Fatalf("GetN(%s) = %v", key, got)
+		}
+	}
+
+	last := all[len(all)-1]
+	var keys []string
+	for i := 0; len(keys) < 100; i++ {
+		if key := "key" + strconv.Itoa(i); j.Get(key) == last {
+			keys = append(keys, key)
+		}
+	}
+	successors := make([]string, len(keys))
+	for i, key := range keys {
+		successors[i] = j.GetN(key, 2)[1]
+	}
+	j.Remove(last)
+	for i, key := range keys {
+		if got := j.Get(key); got != successors[i] {
+			t.Fatalf("%s: expect successor %s after removing %s, got %s", key, successors[i], last, got)
+		}
+	}
+}
+
+func TestPickerMembers(t *testing.T) {
+	for _, tc := range pickers {
+		p := tc.new()
+		if p.Get("key") != "" {
+			t.Fatalf("%s: empty picker should yield empty string", tc.name)
+		}
+		p.Add("b", "a", "b")
+		if m := p.Members(); len(m) != 2 || m[0] != "a" || m[1] != "b" {
+			t.Fatalf("%s: unexpected members %v", tc.name, m)
+		}
+		p.Remove("a")
+		if got := p.Get("key"); got != "b" {
+			t.Fatalf("%s: expect b, got %s", tc.name, got)
+		}
+	}
+}
+
+func benchmarkPickerGet(b *testing.B, newPicker func() Picker, nodes int) {
+	p := newPicker()
+	p.Add(nodeNames(nodes)...)
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = "key" + strconv.Itoa(i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.Get(keys[i%len(keys)])
+	}
+}
+
+func BenchmarkPickerGet(b *testing.B) {
+	for _, tc := range pickers {
+		for _, nodes := range []int{10, 100} {
+			b.Run(fmt.Sprintf("%s/%d", tc.name, nodes), func(b *testing.B) {
+				benchmarkPickerGet(b, tc.new, nodes)
+			})
+		}
+	}
+}
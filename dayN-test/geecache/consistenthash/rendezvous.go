@@ -0,0 +1,112 @@
+package consistenthash
+
+import (
+	"math"
+	"sort"
+)
+
+// Rendezvous 实现最高随机权重（HRW，rendezvous）哈希：
+// 对每个节点计算 score(node, key)，得分最高的节点负责该 key。
+// 不需要虚拟节点，分布天然均匀；增删节点时只有涉及该节点的 key 会迁移。
+// 查找是 O(节点数)，适合节点不多的集群
+type Rendezvous struct {
+	nodes []rendezvousNode
+}
+
+type rendezvousNode struct {
+	name   string
+	hash   uint64
+	weight float64
+}
+
+func NewRendezvous() *Rendezvous {
+	return &Rendezvous{}
+}
+
+// Add 添加权重为 1 的节点；已存在的节点会被忽略
+func (r *Rendezvous) Add(nodes ...string) {
+	for _, node := range nodes {
+		r.AddWeighted(node, 1)
+	}
+}
+
+// AddWeighted 添加带权重的节点，分到的 key 数量与 weight 成正比。
+// weight <= 0 或节点已存在时忽略
+func (r *Rendezvous) AddWeighted(node string, weight int) {
+	if weight <= 0 || r.index(node) >= 0 {
+		return
+	}
+	r.nodes = append(r.nodes, rendezvousNode{name: node, hash: hash64(node), weight: float64(weight)})
+}
+
+// Remove 删除节点，只有原本由它负责的 key 会迁移
+func (r *Rendezvous) Remove(nodes ...string) {
+	for _, node := range nodes {
+		if i := r.index(node); i >= 0 {
+			r.nodes = append(r.nodes[:i], r.nodes[i+1:]...)
+		}
+	}
+}
+
+// Members 返回所有节点，按字典序排列
+func (r *Rendezvous) Members() []string {
+	nodes := make([]string, len(r.nodes))
+	for i, n := range r.nodes {
+		nodes[i] = n.name
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// Get 返回得分最高的节点
+func (r *Rendezvous) Get(key string) string {
+	if len(r.nodes) == 0 {
+		return ""
+	}
+	kh := hash64(key)
+	best, bestScore := "", math.Inf(-1)
+	for _, n := range r.nodes {
+		if score := n.score(kh); score > bestScore {
+			best, bestScore = n.name, score
+		}
+	}
+	return best
+}
+
+// GetN 返回得分最高的 n 个节点，按得分从高到低排列
+func (r *Rendezvous) GetN(key string, n int) []string {
+	if len(r.nodes) == 0 || n <= 0 {
+		return nil
+	}
+	kh := hash64(key)
+	nodes := append([]rendezvousNode(nil), r.nodes...)
+	scores := make(map[string]float64, len(nodes))
+	for _, node := range nodes {
+		scores[node.name] = node.score(kh)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return scores[nodes[i].name] > scores[nodes[j].name]
+	})
+	names := make([]string, min(n, len(nodes)))
+	for i := range names {
+		names[i] = nodes[i].name
+	}
+	return names
+}
+
+// score 计算节点对 key 的得分。
+// 带权重时使用对数法：score = -weight / ln(u)，u 为 (0,1) 内的均匀哈希值
+func (n rendezvousNode) score(keyHash uint64) float64 {
+	h := mix64(keyHash ^ n.hash)
+	u := (float64(h>>11) + 0.5) / (1 << 53) // 映射到 (0,1)
+	return -n.weight / math.Log(u)
+}
+
+func (r *Rendezvous) index(node string) int {
+	for i, n := range r.nodes {
+		if n.name == node {
+			return i
+		}
+	}
+	return -1
+}
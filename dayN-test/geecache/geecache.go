@@ -6,18 +6,36 @@
 package geecache
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"geecache/lru"
+	"geecache/singleflight"
 	"log"
+	"math/rand"
 	"sync"
+	"time"
 )
 
 type Group struct {
 	name      string
-	getter    Getter
+	getter    ContextGetter // NewGroup 会把普通 Getter 适配为 ContextGetter
 	mainCache cache
+	// hotCache 存放本节点不负责、但从远程节点拉取过的热点数据，
+	// 避免热门 key 的每次请求都跨网络；只按一定概率写入，容量是 cacheBytes 的 1/hotCacheFraction
+	hotCache cache
+	// negCache 记录 Getter 返回 ErrNotFound 的 key，只在 WithNegativeCache 开启时使用
+	negCache cache
 	// 依赖注入： 将一个对象所依赖的其他对象，通过外部的方式传递给它，而不是由它自己创建的方式，就是依赖注入。
 	// 在 Group 结构体中使用 PeerPicker 接口作为字段，并通过 RegisterPeers 方法注入具体的 PeerPicker 实现，是依赖注入这一设计模式的典型应用，同时也遵循了面向接口编程的设计原则。
 	peers PeerPicker // NEW: peers字段 分布式场景下的"选点"抽象接口，当Group发生缓存未命中时，他会调用peers的方法（例如 PickPeer(key string)），将key传入远程节点，通过该节点的代理对象（httpGetter）获取数据
+	// loader 保证同一个 key 的并发加载（远程或本地）只会执行一次
+	loader *singleflight.Group
+	// batch 非 nil 时，GetMany 用它一次性加载本地负责的 key
+	batch BatchGetter
+
+	// Stats 是该 Group 的统计计数器
+	Stats Stats
 }
 
 // Getter 是用户回调接口：当本地和远程都未命中时，调用它从源头加载数据
@@ -31,12 +49,88 @@ func (f GetterFunc) Get(key string) ([]byte, error) {
 	return f(key)
 }
 
+// ContextGetter 是带 context 的回调接口，回源时可以感知调用方的超时与取消
+type ContextGetter interface {
+	GetContext(ctx context.Context, key string) ([]byte, error)
+}
+
+// ContextGetterFunc 是 ContextGetter 的接口型函数。
+// 它同时实现了 Getter，因此可以直接传给 NewGroup
+type ContextGetterFunc func(ctx context.Context, key string) ([]byte, error)
+
+func (f ContextGetterFunc) GetContext(ctx context.Context, key string) ([]byte, error) {
+	return f(ctx, key)
+}
+
+func (f ContextGetterFunc) Get(key string) ([]byte, error) {
+	return f(context.Background(), key)
+}
+
+// getterAdapter 把旧的 Getter 适配为 ContextGetter，ctx 被忽略
+type getterAdapter struct {
+	Getter
+}
+
+func (a getterAdapter) GetContext(_ context.Context, key string) ([]byte, error) {
+	return a.Get(key)
+}
+
+// toContextGetter 若 getter 已实现 ContextGetter 则直接使用，否则包一层适配器
+func toContextGetter(getter Getter) ContextGetter {
+	if cg, ok := getter.(ContextGetter); ok {
+		return cg
+	}
+	return getterAdapter{getter}
+}
+
 var (
 	mu     sync.RWMutex
 	groups = make(map[string]*Group)
 )
 
-func NewGroup(name string, cacheBytes int64, getter Getter) *Group {
+const hotCacheFraction = 8 // hotCache 占 cacheBytes 的 1/8，其余留给 mainCache
+
+// hotCachePercent 从远程节点拉取的值写入 hotCache 的概率（百分比）。
+// 只缓存一部分，使得只有真正频繁访问的 key 才大概率留在 hotCache 中
+var hotCachePercent = 10
+
+// GroupOption 用于在 NewGroup 时定制 Group
+type GroupOption func(*Group)
+
+// WithTTL 设置 Group 中缓存记录的默认过期时间。第一次写入缓存后，
+// 后台 goroutine 每隔该间隔清理一次过期记录，不再使用 Group 时调用 Close 停止它
+func WithTTL(ttl time.Duration) GroupOption {
+	return func(g *Group) {
+		g.mainCache.ttl = ttl
+		g.hotCache.ttl = ttl
+	}
+}
+
+// WithPolicy 设置 Group 的缓存淘汰策略，例如 lfu.NewStore、arc.NewStore；默认为 LRU
+func WithPolicy(policy lru.Policy) GroupOption {
+	return func(g *Group) {
+		g.mainCache.policy = policy
+		g.hotCache.policy = policy
+	}
+}
+
+// WithTinyLFU 在 mainCache 的淘汰策略前加上 W-TinyLFU 准入：
+// 新加载的值只有在估计访问频率高于将被淘汰的记录时才会留在缓存中
+func WithTinyLFU() GroupOption {
+	return func(g *Group) {
+		g.mainCache.admission = true
+	}
+}
+
+// WithShards 把 mainCache 分成 n 个独立加锁的分片，容量平均分配，
+// 在多核高并发下减少锁争用；默认 1 个分片
+func WithShards(n int) GroupOption {
+	return func(g *Group) {
+		g.mainCache.nshards = n
+	}
+}
+
+func NewGroup(name string, cacheBytes int64, getter Getter, opts ...GroupOption) *Group {
 	if getter == nil {
 		panic("nil Getter")
 	}
@@ -44,8 +138,14 @@ func NewGroup(name string, cacheBytes int64, getter Getter) *Group {
 	defer mu.Unlock()
 	g := &Group{
 		name:      name,
-		getter:    getter,
-		mainCache: cache{cacheBytes: cacheBytes},
+		getter:    toContextGetter(getter),
+		mainCache: cache{cacheBytes: cacheBytes - cacheBytes/hotCacheFraction},
+		hotCache:  cache{cacheBytes: cacheBytes / hotCacheFraction},
+		loader:    &singleflight.Group{},
+	}
+	g.batch, _ = getter.(BatchGetter)
+	for _, opt := range opts {
+		opt(g)
 	}
 	groups[name] = g
 	return g
@@ -59,16 +159,106 @@ func GetGroup(name string) *Group {
 }
 
 func (g *Group) Get(key string) (ByteView, error) {
+	return g.GetContext(context.Background(), key)
+}
+
+// GetContext 与 Get 相同，但 ctx 会一路传递到远程节点和 Getter，
+// ctx 被取消或超时时立即返回 ctx.Err()
+func (g *Group) GetContext(ctx context.Context, key string) (ByteView, error) {
 	if key == "" {
 		return ByteView{}, fmt.Errorf("key is required")
 	}
 
-	if v, ok := g.mainCache.get(key); ok {
+	g.Stats.Gets.Add(1)
+	if v, ok := g.lookupCache(key); ok {
+		g.Stats.CacheHits.Add(1)
 		log.Println("[GeeCache] hit")
 		return v, nil
 	}
+	if err := g.lookupNegative(key); err != nil {
+		g.Stats.NegativeHits.Add(1)
+		return ByteView{}, err
+	}
+
+	return g.load(ctx, key)
+}
 
-	return g.load(key)
+// Set 把 value 写入 key 的负责节点，等价于 SetContext(context.Background(), key, value)
+func (g *Group) Set(key string, value []byte) error {
+	return g.SetContext(context.Background(), key, value)
+}
+
+// SetContext 把 value 写入 key 的负责节点的 mainCache，用于主动推送刚算出的新值：
+// 负责节点是远程节点时通过 PeerSetter 转发，它不支持写入或不可用时返回错误（不会改写到其他节点）；
+// 负责节点是本节点时直接写入 mainCache。本节点 hotCache 中的旧值会被同时替换。
+// 只有本节点和负责节点负缓存中的记录被清除，其他节点要等负缓存过期才能看到新值；
+// 需要立即在整个集群生效时先调用 Remove
+func (g *Group) SetContext(ctx context.Context, key string, value []byte) error {
+	if key == "" {
+		return fmt.Errorf("key is required")
+	}
+	v := ByteView{b: cloneBytes(value)}
+	g.negCache.remove(key)
+	if g.peers != nil && !NoForward(ctx) {
+		if peer, ok := g.pickOwner(key); ok {
+			ps, ok := peer.(PeerSetter)
+			if !ok {
+				return fmt.Errorf("geecache: peer %T does not support Set", peer)
+			}
+			if err := ps.Set(ctx, g.name, key, v.b); err != nil {
+				return err
+			}
+			g.Stats.PeerSets.Add(1)
+			if g.hotCache.contains(key) {
+				g.hotCache.add(key, v)
+			}
+			return nil
+		}
+	}
+	g.Stats.Sets.Add(1)
+	g.populateCache(key, v)
+	return nil
+}
+
+// pickOwner 返回 key 在哈希环上的负责节点，PeerPicker 未实现 OwnerPicker 时使用 PickPeer
+func (g *Group) pickOwner(key string) (PeerGetter, bool) {
+	if op, ok := g.peers.(OwnerPicker); ok {
+		return op.PickOwner(key)
+	}
+	return g.peers.PickPeer(key)
+}
+
+// GetWithTTL 与 GetContext 相同，同时返回值的剩余有效期，0 表示不过期。
+// 传输层把它随值一起返回给远程节点，使远程节点上的副本不会比本节点的值活得更久；
+// 值已经到期（例如刚好在返回前过期）时返回 1ms 而不是 0
+func (g *Group) GetWithTTL(ctx context.Context, key string) (ByteView, time.Duration, error) {
+	v, err := g.GetContext(ctx, key)
+	if err != nil || v.expire.IsZero() {
+		return v, 0, err
+	}
+	return v, max(v.expire.Sub(g.mainCache.clock()), time.Millisecond), nil
+}
+
+// Close 停止 WithTTL 启动的后台清理 goroutine。Close 之后 Group 仍可正常使用，
+// 只是过期记录不再被主动回收，只在被访问或淘汰时删除
+func (g *Group) Close() {
+	g.mainCache.close()
+	g.hotCache.close()
+	g.negCache.close()
+}
+
+// TTL 返回 WithTTL 设置的缓存有效期，0 表示永不过期。
+// 需要单个值的剩余有效期时使用 GetWithTTL
+func (g *Group) TTL() time.Duration {
+	return g.mainCache.ttl
+}
+
+// lookupCache 依次查找 mainCache 和 hotCache
+func (g *Group) lookupCache(key string) (ByteView, bool) {
+	if v, ok := g.mainCache.get(key); ok {
+		return v, true
+	}
+	return g.hotCache.get(key)
 }
 
 // NEW:
@@ -87,51 +277,143 @@ func (g *Group) RegisterPeers(peers PeerPicker) {
 
 // PERF:
 // load 负责缓存未命中时的数据获取策略：
-// 1. 如果注册了 g.peers（"选点"抽象接口），先通过 g.peers.PickPeer 选节点并尝试远程拉取
-// 2. 远程失败或未注册 peers，回退到本地回调
-func (g *Group) load(key string) (value ByteView, err error) {
-	if g.peers != nil { // 如果注册了 PeerPicker（即处于分布式模式）
-		// 通过一致性哈希选出负责该 key 的节点（PeerGetter）
-		if peer, ok := g.peers.PickPeer(key); ok {
-			// 如果选中了远程节点，就调用 getFromPeer 向它发起请求，取出缓存数据
-			if value, err = g.getFromPeer(peer, key); err == nil {
+//  1. 如果注册了 g.peers（"选点"抽象接口），先通过 g.peers.PickPeer 选节点并尝试远程拉取；
+//     若 g.peers 实现了 ReplicaPicker，负责节点失败时依次尝试环上的接替节点
+//  2. 远程全部失败或未注册 peers，回退到本地回调。
+//     只有节点不可用（网络错误、超时、熔断等）才尝试下一个节点；负责节点给出的确定结果
+//     （ErrNotFound、ErrRemoteLoad）直接返回，避免一个坏 key 在每个节点上都回源一次
+//
+// 整个过程包在 g.loader.DoChan 中，同一个 key 的并发未命中只会触发一次远程请求或一次 Getter.Get。
+// 合并后的加载继承第一个调用者 ctx 中的值和截止时间，但不随它取消：
+// 第一个调用者放弃时，其余调用者仍能拿到结果。每个调用者只在自己的 ctx 结束时提前返回。
+func (g *Group) load(ctx context.Context, key string) (value ByteView, err error) {
+	ch := g.loader.DoChan(key, func() (interface{}, error) {
+		ctx, cancel := detachContext(ctx)
+		defer cancel()
+
+		// 可能在上一次合并的加载刚结束、key 已被删除时进入这里，
+		// 此时数据已在缓存中，再检查一次避免重复回源
+		if v, ok := g.lookupCache(key); ok {
+			return v, nil
+		}
+		if err := g.lookupNegative(key); err != nil {
+			return nil, err
+		}
+		// 如果注册了 PeerPicker（即处于分布式模式），依次尝试负责该 key 的节点及其接替者
+		for i, peer := range g.pickPeers(ctx, key) {
+			pctx := ctx
+			if i > 0 {
+				// 接替节点并不负责该 key，让它直接在本地加载
+				pctx = WithNoForward(ctx)
+			}
+			// 调用 getFromPeer 向远程节点发起请求，取出缓存数据
+			value, ttl, err := g.getFromPeer(pctx, peer, key)
+			if err == nil {
+				g.Stats.PeerLoads.Add(1)
+				// 按概率写入 hotCache，热门 key 下次可以直接在本地命中
+				if rand.Intn(100) < hotCachePercent {
+					g.hotCache.addWithTTL(key, value, ttl)
+				}
 				return value, nil // 直接返回数据
 			}
-			log.Println("[GeeCache] Failed to get from peer", err) // 远程拉取出错时，打印日志，继续回退到本地获取
+			// 负责节点确认 key 不存在，结果是确定的，不再尝试接替节点或本地回调
+			if errors.Is(err, ErrNotFound) {
+				return nil, g.recordNotFound(key, err)
+			}
+			g.Stats.PeerErrors.Add(1)
+			// 负责节点的 Getter 失败，换一个节点回源也只会再失败一次
+			if errors.Is(err, ErrRemoteLoad) {
+				return nil, err
+			}
+			log.Println("[GeeCache] Failed to get from peer", err) // 远程拉取出错时，打印日志，尝试下一个节点
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
 		}
+
+		// 分布式模式未命中或未启用 peers，调用本地回调加载并缓存
+		return g.getLocally(ctx, key)
+	})
+
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return ByteView{}, res.Err
+		}
+		return res.Val.(ByteView), nil
+	case <-ctx.Done():
+		return ByteView{}, ctx.Err()
 	}
+}
 
-	// 分布式模式未命中或未启用 peers，调用本地回调加载并缓存
-	return g.getLocally(key)
+// detachContext 返回一个保留 ctx 的值和截止时间、但不会因 ctx 被取消而结束的 context
+func detachContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	detached := context.WithoutCancel(ctx)
+	if deadline, ok := ctx.Deadline(); ok {
+		return context.WithDeadline(detached, deadline)
+	}
+	return context.WithCancel(detached)
 }
 
-func (g *Group) getLocally(key string) (ByteView, error) {
-	bytes, err := g.getter.Get(key)
+func (g *Group) getLocally(ctx context.Context, key string) (ByteView, error) {
+	bytes, err := g.getter.GetContext(ctx, key)
 	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return ByteView{}, g.recordNotFound(key, err)
+		}
+		g.Stats.LocalLoadErrs.Add(1)
 		return ByteView{}, err
 	}
-	value := ByteView{b: cloneBytes(bytes)}
-	g.populateCache(key, value)
-	return value, nil
+	g.Stats.LocalLoads.Add(1)
+	return g.populateCache(key, ByteView{b: cloneBytes(bytes)}), nil
+}
+
+// pickPeers 返回应依次尝试的远程节点；返回空表示应在本地加载
+func (g *Group) pickPeers(ctx context.Context, key string) []PeerGetter {
+	if g.peers == nil || NoForward(ctx) {
+		return nil
+	}
+	if rp, ok := g.peers.(ReplicaPicker); ok {
+		return rp.PickPeers(key)
+	}
+	// 通过一致性哈希选出负责该 key 的节点（PeerGetter）
+	if peer, ok := g.peers.PickPeer(key); ok {
+		return []PeerGetter{peer}
+	}
+	return nil
 }
 
 // 将从源头或远程获取的数据添加到本地缓存
-func (g *Group) populateCache(key string, value ByteView) {
-	g.mainCache.add(key, value)
+func (g *Group) populateCache(key string, value ByteView) ByteView {
+	return g.mainCache.add(key, value)
 }
 
 // NEW:
 // getFromPeer 通过 PeerGetter 接口从远程节点获取缓存数据
 // 将 “网络字节” 转换为本地 ByteView 结构。
 // - peer "代表远程节点客户端"  ---谁来取值
-func (g *Group) getFromPeer(peer PeerGetter, key string) (ByteView, error) {
+// 返回的 ttl 是远程节点给出的有效期（见 TTLPeerGetter），0 表示未知或不过期
+func (g *Group) getFromPeer(ctx context.Context, peer PeerGetter, key string) (ByteView, time.Duration, error) {
+	// 能给出有效期的节点（如 gRPC）一并取回 ttl
+	if tp, ok := peer.(TTLPeerGetter); ok {
+		bytes, ttl, err := tp.GetWithTTL(ctx, g.name, key)
+		if err != nil {
+			return ByteView{}, 0, err
+		}
+		v := ByteView{b: bytes}
+		if ttl > 0 {
+			v.expire = g.mainCache.clock().Add(ttl)
+		}
+		return v, ttl, nil
+	}
 	// 向远程 peer 发起 Get请求，参数是当前的 group 的 name（命名空间）和具体的 key
 	// peer 在这里是*httpGetter，它知道怎样通过 HTTP 向某台缓存服务器（由 peer 标识）发起请求。
-	bytes, err := peer.Get(g.name, key)
+	// 旧的 PeerGetter 通过 toContextPeerGetter 适配，ctx 会被忽略
+	bytes, err := toContextPeerGetter(peer).GetContext(ctx, g.name, key)
 	if err != nil {
 		// 如果远程调用失败（网络、对段错误等）,将错误向上层返回
-		return ByteView{}, err
+		return ByteView{}, 0, err
 	}
 	// 成功拿到字节后，直接用这些字节构造一个只读的ByteView返回
-	return ByteView{b: bytes}, nil
+	return ByteView{b: bytes}, 0, nil
 }
@@ -0,0 +1,186 @@
+package geecache
+
+import (
+	"context"
+	"fmt"
+	"geecache/lfu"
+	"geecache/tinylfu"
+	"log"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var db = map[string]string{
+	"Tom":  "630",
+	"Jack": "589",
+	"Sam":  "567",
+}
+
+func TestGetter(t *testing.T) {
+	var f Getter = GetterFunc(func(key string) ([]byte, error) {
+		return []byte(key), nil
+	})
+
+	expect := []byte("key")
+	if v, _ := f.Get("key"); !reflect.DeepEqual(v, expect) {
+		t.Fatal("callback failed")
+	}
+}
+
+func TestGet(t *testing.T) {
+	loadCounts := make(map[string]int, len(db))
+	gee := NewGroup("scores", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) {
+			log.Println("[SlowDB] search key", key)
+			if v, ok := db[key]; ok {
+				loadCounts[key]++
+				return []byte(v), nil
+			}
+			return nil, fmt.Errorf("%s not exist", key)
+		}))
+
+	for k, v := range db {
+		if view, err := gee.Get(k); err != nil || view.String() != v {
+			t.Fatalf("failed to get value of %s", k)
+		}
+		if _, err := gee.Get(k); err != nil || loadCounts[k] > 1 {
+			t.Fatalf("cache %s miss", k)
+		}
+	}
+
+	if view, err := gee.Get("unknown"); err == nil {
+		t.Fatalf("the value of unknow should be empty, but %s got", view)
+	}
+}
+
+// TestGetConcurrent 500 个 goroutine 同时请求同一个冷 key，只应回源一次
+func TestGetConcurrent(t *testing.T) {
+	var loads int32
+	gee := NewGroup("scores-concurrent", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) {
+			atomic.AddInt32(&loads, 1)
+			time.Sleep(10 * time.Millisecond) // 模拟慢查询
+			return []byte(db[key]), nil
+		}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 500; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if view, err := gee.Get("Tom"); err != nil || view.String() != "630" {
+				t.Errorf("failed to get value of Tom: %v %v", view, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&loads); n != 1 {
+		t.Fatalf("getter called %d times, want 1", n)
+	}
+}
+
+func TestGetGroup(t *testing.T) {
+	groupName := "scores"
+	NewGroup(groupName, 2<<10, GetterFunc(
+		func(key string) (bytes []byte, err error) { return }))
+	if group := GetGroup(groupName); group == nil || group.name != groupName {
+		t.Fatalf("group %s not exist", groupName)
+	}
+
+	if group := GetGroup(groupName + "111"); group != nil {
+		t.Fatalf("expect nil, but %s got", group.name)
+	}
+}
+
+func TestGetWithTTL(t *testing.T) {
+	var loads int32
+	gee := NewGroup("scores-ttl", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) {
+			atomic.AddInt32(&loads, 1)
+			return []byte(db[key]), nil
+		}), WithTTL(time.Minute))
+	defer gee.Close()
+	var now atomic.Int64 // 假时钟，UnixNano
+	gee.mainCache.now = func() time.Time { return time.Unix(0, now.Load()) }
+	tick := make(chan time.Time)
+	stopped := make(chan struct{})
+	gee.mainCache.ticks = func(time.Duration) (<-chan time.Time, func()) {
+		return tick, func() { close(stopped) }
+	}
+
+	gee.Get("Tom")
+	gee.Get("Tom")
+	if n := atomic.LoadInt32(&loads); n != 1 {
+		t.Fatalf("getter called %d times before expiry, want 1", n)
+	}
+
+	// 过期后没有任何写入，后台清理也会回收 Tom；第二次 tick 送达时第一次清理已经完成
+	now.Add(int64(time.Minute))
+	tick <- time.Time{}
+	tick <- time.Time{}
+	if st := gee.CacheStats(MainCache); st.Items != 0 || st.Bytes != 0 || st.Evictions != 0 {
+		t.Fatalf("expired entry should be swept without counting as an eviction, got %+v", st)
+	}
+	gee.Get("Tom")
+	if n := atomic.LoadInt32(&loads); n != 2 {
+		t.Fatalf("getter called %d times after expiry, want 2", n)
+	}
+
+	// Close 停止后台清理
+	gee.Close()
+	<-stopped
+}
+
+// TestGetWithRemainingTTL GetWithTTL 返回的是值的剩余有效期，而不是 WithTTL 设置的完整时长
+func TestGetWithRemainingTTL(t *testing.T) {
+	gee := newLocalGroup("remaining-ttl", GetterFunc(dbGet))
+	WithTTL(time.Minute)(gee)
+	defer gee.Close()
+	var now atomic.Int64 // 假时钟，UnixNano
+	gee.mainCache.now = func() time.Time { return time.Unix(0, now.Load()) }
+
+	if _, ttl, err := gee.GetWithTTL(context.Background(), "Tom"); err != nil || ttl != time.Minute {
+		t.Fatalf("expect the full ttl for a fresh load, got %v %v", ttl, err)
+	}
+	now.Add(int64(40 * time.Second))
+	if v, ttl, err := gee.GetWithTTL(context.Background(), "Tom"); err != nil || v.String() != "630" || ttl != 20*time.Second {
+		t.Fatalf("expect 20s left, got %q %v %v", v.String(), ttl, err)
+	}
+
+	local := newLocalGroup("remaining-ttl-none", GetterFunc(dbGet))
+	if _, ttl, err := local.GetWithTTL(context.Background(), "Tom"); err != nil || ttl != 0 {
+		t.Fatalf("expect 0 without WithTTL, got %v %v", ttl, err)
+	}
+}
+
+func TestWithPolicy(t *testing.T) {
+	gee := NewGroup("scores-lfu", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) {
+			return []byte(db[key]), nil
+		}), WithPolicy(lfu.NewStore))
+
+	if view, err := gee.Get("Tom"); err != nil || view.String() != "630" {
+		t.Fatalf("failed to get value of Tom")
+	}
+	if _, ok := gee.mainCache.shards[0].store.(*lfu.Cache); !ok {
+		t.Fatalf("expect main cache to use lfu, got %T", gee.mainCache.shards[0].store)
+	}
+}
+
+func TestWithTinyLFU(t *testing.T) {
+	gee := NewGroup("scores-tinylfu", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) {
+			return []byte(db[key]), nil
+		}), WithTinyLFU())
+
+	if view, err := gee.Get("Tom"); err != nil || view.String() != "630" {
+		t.Fatalf("failed to get value of Tom")
+	}
+	if _, ok := gee.mainCache.shards[0].store.(*tinylfu.Cache); !ok {
+		t.Fatalf("expect main cache to use tinylfu, got %T", gee.mainCache.shards[0].store)
+	}
+}
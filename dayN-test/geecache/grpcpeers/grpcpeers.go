@@ -0,0 +1,170 @@
+// Package grpcpeers 用 gRPC 代替 HTTP 在节点之间拉取缓存。
+// Pool 实现 geecache.PeerPicker，与 HTTPPool 一样基于一致性哈希选点；
+// Server 实现 geecachepb.GeeCacheServer，把请求分发给对应的 Group。用法：
+//
+//	pool := grpcpeers.NewPool(self)
+//	pool.Set(peers...)
+//	group.RegisterPeers(pool)
+//
+//	s := grpc.NewServer()
+//	grpcpeers.Register(s)
+//	s.Serve(lis)
+package grpcpeers
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative geecachepb/geecache.proto
+
+import (
+	"context"
+	"fmt"
+	"geecache"
+	"geecache/consistenthash"
+	"geecache/grpcpeers/geecachepb"
+	"log"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultReplicas = 50
+	// noForwardMD 与 HTTPPool 的 X-Geecache-No-Forward 请求头含义相同：
+	// 收到的节点应在本地加载，不再按哈希环转发
+	noForwardMD = "x-geecache-no-forward"
+	// notFoundMD 出现在 NotFound 错误的 trailer 中，表示 Getter 返回了 ErrNotFound（而不是 group 不存在）
+	notFoundMD = "x-geecache-not-found"
+	// loadErrorMD 出现在 Internal 错误的 trailer 中，表示远程节点的 Getter 加载失败（而不是节点故障）
+	loadErrorMD = "x-geecache-load-error"
+)
+
+// Pool 维护 gRPC 节点的一致性哈希环，以及到每个远程节点的连接
+type Pool struct {
+	self     string
+	dialOpts []grpc.DialOption
+
+	mu      sync.Mutex // 保护 peers 和 getters
+	peers   *consistenthash.Map
+	getters map[string]*grpcGetter // 远程节点地址 -> 客户端
+}
+
+// NewPool 创建一个 Pool，self 是本节点的地址，需与 Set 中的写法一致。
+// 未指定 dialOpts 时使用不加密的连接
+func NewPool(self string, dialOpts ...grpc.DialOption) *Pool {
+	if len(dialOpts) == 0 {
+		dialOpts = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	}
+	return &Pool{self: self, dialOpts: dialOpts}
+}
+
+func (p *Pool) Log(format string, v ...interface{}) {
+	log.Printf("[gRPC Server %s] %s", p.self, fmt.Sprintf(format, v...))
+}
+
+// Set 重建哈希环，并为每个远程节点建立连接；不再属于集群的节点的连接会被关闭
+func (p *Pool) Set(peers ...string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	getters := make(map[string]*grpcGetter, len(peers))
+	for _, peer := range peers {
+		if peer == p.self {
+			continue
+		}
+		if g, ok := p.getters[peer]; ok {
+			getters[peer] = g
+			continue
+		}
+		conn, err := grpc.NewClient(peer, p.dialOpts...)
+		if err != nil {
+			closeGetters(getters, p.getters)
+			return fmt.Errorf("grpcpeers: dial %s: %v", peer, err)
+		}
+		getters[peer] = &grpcGetter{conn: conn, client: geecachepb.NewGeeCacheClient(conn)}
+	}
+	closeGetters(p.getters, getters)
+
+	p.peers = consistenthash.New(defaultReplicas, nil)
+	p.peers.Add(peers...)
+	p.getters = getters
+	return nil
+}
+
+// closeGetters 关闭 old 中不在 keep 里的连接
+func closeGetters(old, keep map[string]*grpcGetter) {
+	for peer, g := range old {
+		if keep[peer] != g {
+			g.conn.Close()
+		}
+	}
+}
+
+// Close 关闭到所有远程节点的连接
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	closeGetters(p.getters, nil)
+	p.peers, p.getters = nil, nil
+	return nil
+}
+
+// PickPeer 根据 key 选出负责它的远程节点，选中自己或没有节点时返回 (nil, false)
+func (p *Pool) PickPeer(key string) (geecache.PeerGetter, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.peers == nil {
+		return nil, false
+	}
+	if peer := p.peers.Get(key); peer != "" && peer != p.self {
+		p.Log("Pick peer %s", peer)
+		return p.getters[peer], true
+	}
+	return nil, false
+}
+
+// grpcGetter 通过一条 gRPC 连接向远程节点拉取缓存
+type grpcGetter struct {
+	conn   *grpc.ClientConn
+	client geecachepb.GeeCacheClient
+}
+
+func (g *grpcGetter) Get(group string, key string) ([]byte, error) {
+	return g.GetContext(context.Background(), group, key)
+}
+
+func (g *grpcGetter) GetContext(ctx context.Context, group string, key string) ([]byte, error) {
+	value, _, err := g.GetWithTTL(ctx, group, key)
+	return value, err
+}
+
+// GetWithTTL 发起一次 Get 调用，ctx 的截止时间由 gRPC 自动传给远程节点；ttl 取自响应中的 Ttl，
+// Group 据此限制本地 hotCache 副本的有效期（实现了 geecache.TTLPeerGetter 接口）。
+// 远程节点上 key 不存在时返回 *geecache.NotFoundError，Getter 加载失败时返回 *geecache.RemoteLoadError
+func (g *grpcGetter) GetWithTTL(ctx context.Context, group string, key string) ([]byte, time.Duration, error) {
+	if geecache.NoForward(ctx) {
+		ctx = metadata.AppendToOutgoingContext(ctx, noForwardMD, "1")
+	}
+	var trailer metadata.MD
+	res, err := g.client.Get(ctx, &geecachepb.GetRequest{Group: group, Key: key}, grpc.Trailer(&trailer))
+	if err != nil {
+		if len(trailer.Get(notFoundMD)) > 0 {
+			return nil, 0, &geecache.NotFoundError{Msg: status.Convert(err).Message()}
+		}
+		if len(trailer.Get(loadErrorMD)) > 0 {
+			return nil, 0, &geecache.RemoteLoadError{Msg: status.Convert(err).Message()}
+		}
+		return nil, 0, err
+	}
+	return res.GetValue(), time.Duration(res.GetTtl()) * time.Millisecond, nil
+}
+
+var (
+	_ geecache.PeerPicker        = (*Pool)(nil)
+	_ geecache.PeerGetter        = (*grpcGetter)(nil)
+	_ geecache.ContextPeerGetter = (*grpcGetter)(nil)
+	_ geecache.TTLPeerGetter     = (*grpcGetter)(nil)
+)
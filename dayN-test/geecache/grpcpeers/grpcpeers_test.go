@@ -0,0 +1,142 @@
+package grpcpeers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"geecache"
+	"geecache/grpcpeers/geecachepb"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// startServer 在内存中的 bufconn 上启动 gRPC 服务，返回连接它的 DialOption
+func startServer(t *testing.T) grpc.DialOption {
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	Register(s)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	return grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return lis.DialContext(ctx)
+	})
+}
+
+func newTestPool(t *testing.T, peers ...string) *Pool {
+	pool := NewPool("self", startServer(t), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err := pool.Set(peers...); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pool.Close() })
+	return pool
+}
+
+func TestPoolGet(t *testing.T) {
+	var loads int32
+	geecache.NewGroup("grpc-scores", 2<<10, geecache.GetterFunc(
+		func(key string) ([]byte, error) {
+			atomic.AddInt32(&loads, 1)
+			if key == "Tom" {
+				return []byte("630"), nil
+			}
+			if key == "missing" {
+				return nil, fmt.Errorf("%s not exist: %w", key, geecache.ErrNotFound)
+			}
+			return nil, errors.New(key + " not exist")
+		}), geecache.WithTTL(time.Minute))
+
+	pool := newTestPool(t, "self", "passthrough:///remote")
+	var peer geecache.PeerGetter
+	for _, key := range []string{"Tom", "Jack", "Sam", "a", "b", "c"} {
+		if p, ok := pool.PickPeer(key); ok {
+			peer = p
+			break
+		}
+	}
+	if peer == nil {
+		t.Fatal("expect some keys owned by the remote peer")
+	}
+
+	for i := 0; i < 2; i++ {
+		if v, err := peer.Get("grpc-scores", "Tom"); err != nil || string(v) != "630" {
+			t.Fatalf("failed to get Tom: %q %v", v, err)
+		}
+	}
+	if loads != 1 {
+		t.Fatalf("expect remote cache hit on second get, loaded %d times", loads)
+	}
+	if _, err := peer.Get("grpc-scores", "unknown"); !errors.Is(err, geecache.ErrRemoteLoad) {
+		t.Fatalf("expect ErrRemoteLoad for a failed load, got %v", err)
+	}
+	if _, err := peer.Get("grpc-scores", "missing"); !errors.Is(err, geecache.ErrNotFound) || err.Error() != "missing not exist: geecache: not found" {
+		t.Fatalf("expect ErrNotFound for a missing key, got %v", err)
+	}
+	if _, err := peer.Get("no-such-group", "Tom"); status.Code(err) != codes.NotFound || errors.Is(err, geecache.ErrNotFound) {
+		t.Fatalf("expect NotFound for unknown group, got %v", err)
+	}
+}
+
+func TestServerTTL(t *testing.T) {
+	geecache.NewGroup("grpc-ttl", 2<<10, geecache.GetterFunc(
+		func(key string) ([]byte, error) { return []byte(key), nil }), geecache.WithTTL(time.Minute))
+
+	res, err := (&Server{}).Get(context.Background(), nil)
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expect NotFound for empty request, got %v %v", res, err)
+	}
+	pool := newTestPool(t, "passthrough:///remote")
+	getter := pool.getters["passthrough:///remote"]
+	res, err = getter.client.Get(context.Background(), &geecachepb.GetRequest{Group: "grpc-ttl", Key: "Tom"})
+	if err != nil || string(res.GetValue()) != "Tom" || res.GetTtl() <= 0 || res.GetTtl() > time.Minute.Milliseconds() {
+		t.Fatalf("unexpected response %v %v", res, err)
+	}
+	if v, ttl, err := getter.GetWithTTL(context.Background(), "grpc-ttl", "Tom"); err != nil || string(v) != "Tom" || ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("expect the remote ttl from GetWithTTL, got %q %v %v", v, ttl, err)
+	}
+}
+
+// panicPicker 任何转发都视为错误
+type panicPicker struct{}
+
+func (panicPicker) PickPeer(key string) (geecache.PeerGetter, bool) {
+	panic("unexpected PickPeer for " + key)
+}
+
+// TestNoForward 客户端 ctx 的"不要转发"标记通过 metadata 传给远程节点
+func TestNoForward(t *testing.T) {
+	remote := geecache.NewGroup("grpc-no-forward", 2<<10, geecache.GetterFunc(
+		func(key string) ([]byte, error) { return []byte("v"), nil }))
+	remote.RegisterPeers(panicPicker{})
+
+	pool := newTestPool(t, "passthrough:///remote")
+	getter := pool.getters["passthrough:///remote"]
+	ctx := geecache.WithNoForward(context.Background())
+	if v, err := getter.GetContext(ctx, "grpc-no-forward", "Tom"); err != nil || string(v) != "v" {
+		t.Fatalf("failed to get without forwarding: %q %v", v, err)
+	}
+}
+
+// TestDeadline 客户端的截止时间传给远程节点的 Getter，超时后返回 DeadlineExceeded
+func TestDeadline(t *testing.T) {
+	geecache.NewGroup("grpc-deadline", 2<<10, geecache.ContextGetterFunc(
+		func(ctx context.Context, key string) ([]byte, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}))
+
+	pool := newTestPool(t, "passthrough:///remote")
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := pool.getters["passthrough:///remote"].GetContext(ctx, "grpc-deadline", "Tom")
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("expect DeadlineExceeded, got %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package grpcpeers
+
+import (
+	"context"
+	"errors"
+	"geecache"
+	"geecache/grpcpeers/geecachepb"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Server 实现 geecachepb.GeeCacheServer，把请求分发给 geecache.GetGroup(name)
+type Server struct {
+	geecachepb.UnimplementedGeeCacheServer
+}
+
+// Register 在 s 上注册 GeeCache 服务
+func Register(s *grpc.Server) {
+	geecachepb.RegisterGeeCacheServer(s, &Server{})
+}
+
+// Get 在对应的 Group 中查找 key，Group 不存在时返回 NotFound；
+// key 不存在（geecache.ErrNotFound）时同样返回 NotFound，并在 trailer 中带上 notFoundMD 以示区分；
+// Getter 加载失败时返回 Internal，并在 trailer 中带上 loadErrorMD；
+// 客户端的截止时间和取消会通过 ctx 传给 Group 和 Getter。响应中的 Ttl 是值在本节点缓存中的剩余有效期
+func (s *Server) Get(ctx context.Context, req *geecachepb.GetRequest) (*geecachepb.GetResponse, error) {
+	group := geecache.GetGroup(req.GetGroup())
+	if group == nil {
+		return nil, status.Errorf(codes.NotFound, "no such group: %s", req.GetGroup())
+	}
+	group.Stats.ServerRequests.Add(1)
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get(noForwardMD)) > 0 {
+		ctx = geecache.WithNoForward(ctx)
+	}
+	view, ttl, err := group.GetWithTTL(ctx, req.GetKey())
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+		if errors.Is(err, geecache.ErrNotFound) {
+			grpc.SetTrailer(ctx, metadata.Pairs(notFoundMD, "1"))
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		grpc.SetTrailer(ctx, metadata.Pairs(loadErrorMD, "1"))
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	// 向上取整到毫秒，不足 1ms 的剩余有效期不能变成表示"不过期"的 0
+	ms := (ttl + time.Millisecond - 1) / time.Millisecond
+	return &geecachepb.GetResponse{Value: view.ByteSlice(), Ttl: int64(ms)}, nil
+}
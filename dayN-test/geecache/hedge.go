@@ -0,0 +1,127 @@
+package geecache
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// HedgeOptions 是对冲请求的参数：发往负责节点的请求超过一定时长仍未返回时，
+// 向环上的下一个节点再发一份相同的请求，先返回的结果胜出，另一份被取消。
+// 对冲延迟取负责节点历史耗时的 Quantile 分位数，只让最慢的一小部分请求多发一次
+type HedgeOptions struct {
+	Quantile   float64       // 对冲延迟取该分位数的耗时，默认 0.95
+	MinDelay   time.Duration // 对冲延迟的下限；样本数不足时直接使用它
+	MinSamples uint64        // 负责节点的耗时样本数达到该值后才按分位数计算
+}
+
+// DefaultHedgeOptions 按 p95 耗时对冲，至少等待 5ms
+var DefaultHedgeOptions = HedgeOptions{
+	Quantile:   0.95,
+	MinDelay:   5 * time.Millisecond,
+	MinSamples: 20,
+}
+
+// delay 根据负责节点的耗时直方图计算对冲延迟
+func (o *HedgeOptions) delay(latency *histogram) time.Duration {
+	q := o.Quantile
+	if q <= 0 || q >= 1 {
+		q = DefaultHedgeOptions.Quantile
+	}
+	if latency == nil || atomic.LoadUint64(&latency.count) < max(o.MinSamples, 1) {
+		return o.MinDelay
+	}
+	return max(latency.quantile(q), o.MinDelay)
+}
+
+// hedgedGetter 先请求 primary，primary 在 delay 内没有返回或已经失败时再请求 secondary
+type hedgedGetter struct {
+	primary   *httpGetter
+	secondary *httpGetter
+	diverted  bool // primary 也不是负责节点（负责节点已熔断），请求同样带上 NoForward
+	delay     time.Duration
+	hedges    *int64 // 发出对冲请求的次数，原子访问，可以为 nil
+}
+
+func (h *hedgedGetter) Get(group string, key string) ([]byte, error) {
+	return h.GetContext(context.Background(), group, key)
+}
+
+// GetContext 返回最先成功的结果，并取消另一份请求；两份都失败时返回最后一个错误。
+// ErrNotFound 和 ErrRemoteLoad 是确定的结果，与成功一样直接返回
+func (h *hedgedGetter) GetContext(ctx context.Context, group string, key string) ([]byte, error) {
+	// 函数返回时取消仍在进行中的落败请求
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		value []byte
+		err   error
+	}
+	ch := make(chan result, 2)
+	launch := func(peer *httpGetter, ctx context.Context) {
+		go func() {
+			value, err := peer.GetContext(ctx, group, key)
+			ch <- result{value, err}
+		}()
+	}
+
+	if h.diverted {
+		launch(h.primary, WithNoForward(ctx))
+	} else {
+		launch(h.primary, ctx)
+	}
+	pending, hedged := 1, false
+	hedge := func() {
+		hedged = true
+		pending++
+		if h.hedges != nil {
+			atomic.AddInt64(h.hedges, 1)
+		}
+		// secondary 并不负责该 key，让它直接在本地加载
+		launch(h.secondary, WithNoForward(ctx))
+	}
+
+	t := time.NewTimer(h.delay)
+	defer t.Stop()
+	var err error
+	for {
+		select {
+		case <-t.C:
+			if !hedged {
+				hedge()
+			}
+		case res := <-ch:
+			pending--
+			if res.err == nil || errors.Is(res.err, ErrNotFound) || errors.Is(res.err, ErrRemoteLoad) {
+				return res.value, res.err
+			}
+			err = res.err
+			// primary 提前失败时无需等到 delay，立即请求 secondary
+			if !hedged && ctx.Err() == nil {
+				hedge()
+			}
+			if pending == 0 {
+				return nil, err
+			}
+		}
+	}
+}
+
+// Set 写入只发往 primary（负责节点）
+func (h *hedgedGetter) Set(ctx context.Context, group string, key string, value []byte) error {
+	return h.primary.Set(ctx, group, key, value)
+}
+
+// Remove 删除只发往 primary（负责节点）
+func (h *hedgedGetter) Remove(ctx context.Context, group string, key string) error {
+	return h.primary.Remove(ctx, group, key)
+}
+
+var (
+	_ PeerGetter        = (*hedgedGetter)(nil)
+	_ PeerRemover       = (*hedgedGetter)(nil)
+	_ PeerSetter        = (*hedgedGetter)(nil)
+	_ ContextPeerGetter = (*hedgedGetter)(nil)
+)
@@ -0,0 +1,135 @@
+package geecache
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHistogramQuantile(t *testing.T) {
+	h := newHistogram()
+	for i := 0; i < 90; i++ {
+		h.observe(2 * time.Millisecond) // (1ms, 5ms]
+	}
+	for i := 0; i < 10; i++ {
+		h.observe(200 * time.Millisecond) // (100ms, 250ms]
+	}
+	if q := h.quantile(0.5); q <= time.Millisecond || q > 5*time.Millisecond {
+		t.Fatalf("p50 = %v, want in (1ms, 5ms]", q)
+	}
+	if q := h.quantile(0.95); q <= 100*time.Millisecond || q > 250*time.Millisecond {
+		t.Fatalf("p95 = %v, want in (100ms, 250ms]", q)
+	}
+}
+
+// TestHedgedGetter 负责节点迟迟不返回时，对冲请求发往下一个节点，先返回的胜出，慢的请求被取消
+func TestHedgedGetter(t *testing.T) {
+	canceled := make(chan struct{})
+	slow := &httpGetter{baseURL: "http://slow" + defaultBasePath,
+		transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			<-req.Context().Done()
+			close(canceled)
+			return nil, req.Context().Err()
+		})}
+	var noForwardSeen atomic.Bool
+	fast := &httpGetter{baseURL: "http://fast" + defaultBasePath,
+		transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			noForwardSeen.Store(req.Header.Get(noForwardHeader) != "")
+			return &http.Response{StatusCode: http.StatusOK, Status: "200 OK",
+				Body: io.NopCloser(strings.NewReader("fast")), Request: req}, nil
+		})}
+
+	var hedges int64
+	h := &hedgedGetter{primary: slow, secondary: fast, delay: 10 * time.Millisecond, hedges: &hedges}
+	if v, err := h.Get("scores", "Tom"); err != nil || string(v) != "fast" {
+		t.Fatalf("expect hedged result, got %q %v", v, err)
+	}
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("slow request was not canceled")
+	}
+	if hedges != 1 || !noForwardSeen.Load() {
+		t.Fatalf("expect one hedge without forwarding, got %d %v", hedges, noForwardSeen.Load())
+	}
+
+	// 负责节点在对冲延迟内返回时不发出对冲请求
+	h = &hedgedGetter{primary: fast, secondary: slow, delay: time.Second, hedges: &hedges}
+	if v, err := h.Get("scores", "Tom"); err != nil || string(v) != "fast" || hedges != 1 {
+		t.Fatalf("unexpected hedge: %q %v, hedges %d", v, err, hedges)
+	}
+}
+
+func TestHTTPPoolHedging(t *testing.T) {
+	pool := NewHTTPPool("http://self")
+	pool.Set("http://a", "http://b", "http://c")
+	pool.SetHedging(&DefaultHedgeOptions)
+
+	owner := pool.peers.Get("Tom")
+	peer, ok := pool.PickPeer("Tom")
+	hg, isHedged := peer.(*hedgedGetter)
+	if !ok || !isHedged || hg.primary != pool.httpGetters[owner] || hg.secondary == hg.primary {
+		t.Fatalf("expect hedged getter with primary %s, got %#v", owner, peer)
+	}
+	if hg.delay != DefaultHedgeOptions.MinDelay {
+		t.Fatalf("expect MinDelay without samples, got %v", hg.delay)
+	}
+	if peers := pool.PickPeers("Tom"); len(peers) != 1 {
+		t.Fatalf("expect first two owners merged into one hedged getter, got %d", len(peers))
+	}
+
+	pool.SetHedging(nil)
+	if peer, _ := pool.PickPeer("Tom"); peer != pool.httpGetters[owner] {
+		t.Fatalf("expect plain getter when hedging is disabled, got %#v", peer)
+	}
+}
+
+// TestGetManyHedging 启用对冲时 GetMany 仍按节点合并：每个远程节点只收到一次批量请求
+func TestGetManyHedging(t *testing.T) {
+	NewGroup("batch-hedge", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte("remote-" + key), nil
+	}))
+	var batches, singles [2]int32
+	var srvs [2]*httptest.Server
+	for i := range srvs {
+		i := i
+		handler := NewHTTPPool("")
+		srvs[i] = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				atomic.AddInt32(&batches[i], 1)
+			} else {
+				atomic.AddInt32(&singles[i], 1)
+			}
+			handler.ServeHTTP(w, r)
+		}))
+		defer srvs[i].Close()
+	}
+
+	pool := NewHTTPPool("http://self")
+	pool.Set(srvs[0].URL, srvs[1].URL)
+	pool.SetHedging(&DefaultHedgeOptions)
+	local := newLocalGroup("batch-hedge", GetterFunc(func(key string) ([]byte, error) {
+		return nil, errors.New("should not load locally")
+	}))
+	local.RegisterPeers(pool)
+
+	keys := make([]string, 20)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+	values, errs := local.GetMany(keys)
+	if len(values) != len(keys) || len(errs) != 0 {
+		t.Fatalf("unexpected result: %d values, errors %v", len(values), errs)
+	}
+	for i := range srvs {
+		if batches[i] != 1 || singles[i] != 0 {
+			t.Fatalf("peer %d got %d batch and %d single requests, want one batch", i, batches[i], singles[i])
+		}
+	}
+}
@@ -22,19 +22,53 @@ HTTPPool 和 httpGetter 的关系：
 package geecache
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"geecache/consistenthash"
 	"io"
 	"log"
 	"net/http"
 	"net/url"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 const (
 	defaultBasePath = "/_geecache/"
 	defaultReplicas = 50 // NEW: 哈希环中每个真实节点对应的虚拟节点的倍数
+
+	// statsPath 挂在 basePath 下，以 JSON 形式返回所有 Group 的统计信息。
+	// 以下划线开头，不会和正常的 <group>/<key> 路径冲突
+	statsPath = "_stats"
+	// peersPath 以 JSON 形式返回每个远程节点的熔断器状态
+	peersPath = "_peers"
+	// batchPath 是批量获取的前缀：POST <basePath>_batch/<group>，请求体为 JSON {"keys": [...]}
+	batchPath = "_batch/"
+
+	// timeoutHeader 携带客户端 ctx 剩余的超时时间（毫秒）。
+	// 传相对时长而不是绝对截止时间，避免节点间时钟不一致
+	timeoutHeader = "X-Geecache-Timeout"
+	// noForwardHeader 表示请求是故障转移过来的，收到的节点应在本地加载，不再按哈希环转发
+	noForwardHeader = "X-Geecache-No-Forward"
+	// notFoundHeader 出现在 404 响应中，表示 Getter 返回了 ErrNotFound（而不是 group 不存在），
+	// 响应体为原始的错误信息
+	notFoundHeader = "X-Geecache-Not-Found"
+	// loadErrorHeader 出现在 500 响应中，表示节点自己的 Getter 加载失败（而不是节点故障），
+	// 响应体为原始的错误信息
+	loadErrorHeader = "X-Geecache-Load-Error"
+
+	// defaultFailoverPeers 负责节点失败时，连同它在内最多尝试的远程节点个数
+	defaultFailoverPeers = 2
+	// defaultMaxIdleConnsPerPeer 每个远程节点保留的空闲连接数。
+	// http.DefaultTransport 只保留 2 个，节点间请求密集时会频繁新建连接
+	defaultMaxIdleConnsPerPeer = 64
 )
 
 // HTTPPool 的核心职责有：
@@ -44,16 +78,73 @@ const (
 type HTTPPool struct {
 	self        string
 	basePath    string
-	mu          sync.Mutex             // NEW: 保护 peer 和 httpGetters 并发访问（Set 和 PickPeer 会并发读写 peers 和 httpGetters，需要锁来保证操作的原子性及可见性，避免竞态条件。）
-	peers       *consistenthash.Map    // NEW: 一致性哈希环,用于根据 key 选节点
-	httpGetters map[string]*httpGetter // NEW: 映射,(远程节点地址 -> 对应客户端httpGetter )。每一个远程节点对应一个 httpGetter，因为 httpGetter 与远程节点的地址 baseURL 有关。
+	mu          sync.Mutex                   // NEW: 保护 peer 和 httpGetters 并发访问（Set 和 PickPeer 会并发读写 peers 和 httpGetters，需要锁来保证操作的原子性及可见性，避免竞态条件。）
+	peers       consistenthash.Picker        // NEW: 一致性哈希环（或其他放置策略）,用于根据 key 选节点
+	newPicker   func() consistenthash.Picker // 创建放置策略，nil 时使用 CRC32 一致性哈希环
+	weights     map[string]int               // AddWeightedPeers 加入的节点的权重，SetPicker 换策略时沿用
+	httpGetters map[string]*httpGetter       // NEW: 映射,(远程节点地址 -> 对应客户端httpGetter )。每一个远程节点对应一个 httpGetter，因为 httpGetter 与远程节点的地址 baseURL 有关。
+	latencies   map[string]*histogram        // 远程节点地址 -> 请求耗时直方图，重建 httpGetters 时保留
+	loadBound   float64                      // 有界负载的 epsilon，0 表示不启用
+	inflight    int64                        // 本节点正在处理的来自其他节点的 Get 请求数，原子访问；作为本节点自己的负载参与有界负载选点
+	failover    int                          // 最多尝试的远程节点个数，见 PickPeers
+	breakerOpts *CircuitBreakerOptions       // 每个远程节点熔断器的参数，nil 表示不熔断
+	retry       *RetryPolicy                 // 远程请求的重试策略，nil 表示不重试
+	hedge       *HedgeOptions                // 对冲请求的参数，nil 表示不对冲
+	hedges      int64                        // 发出对冲请求的次数，原子访问
+	replicas    int                          // 默认一致性哈希环的虚拟节点倍数
+	hashFn      consistenthash.Hash          // 默认一致性哈希环的哈希函数，nil 表示 CRC32
+	transport   http.RoundTripper            // 所有 httpGetter 共享的 Transport，复用连接池
+	timeout     time.Duration                // 单次远程请求的超时时间，0 表示不限制
+}
+
+// HTTPPoolOptions 是 NewHTTPPoolOpts 的参数，零值字段使用默认值
+type HTTPPoolOptions struct {
+	BasePath string              // 节点间通信的路径前缀，默认 "/_geecache/"
+	Replicas int                 // 一致性哈希环的虚拟节点倍数，默认 50
+	HashFn   consistenthash.Hash // 一致性哈希环的哈希函数，默认 CRC32
+	// Transport 是所有 httpGetter 共享的 http.RoundTripper。
+	// 为 nil 时基于 http.DefaultTransport 创建一个，并按 MaxIdleConnsPerPeer 调整连接池
+	Transport           http.RoundTripper
+	Timeout             time.Duration // 单次远程请求的超时时间（不含重试间隔），默认不限制
+	MaxIdleConnsPerPeer int           // 每个远程节点保留的空闲连接数，默认 64；设置了 Transport 时忽略
 }
 
 func NewHTTPPool(self string) *HTTPPool {
-	return &HTTPPool{
-		self:     self,
-		basePath: defaultBasePath,
+	return NewHTTPPoolOpts(self, nil)
+}
+
+// NewHTTPPoolOpts 按 opts 创建 HTTPPool，opts 为 nil 时等同于 NewHTTPPool
+func NewHTTPPoolOpts(self string, opts *HTTPPoolOptions) *HTTPPool {
+	if opts == nil {
+		opts = &HTTPPoolOptions{}
+	}
+	p := &HTTPPool{
+		self:        self,
+		basePath:    opts.BasePath,
+		failover:    defaultFailoverPeers,
+		breakerOpts: &defaultBreakerOptions,
+		replicas:    opts.Replicas,
+		hashFn:      opts.HashFn,
+		transport:   opts.Transport,
+		timeout:     opts.Timeout,
+	}
+	if p.basePath == "" {
+		p.basePath = defaultBasePath
+	}
+	if p.replicas <= 0 {
+		p.replicas = defaultReplicas
 	}
+	if p.transport == nil {
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		t.MaxIdleConnsPerHost = opts.MaxIdleConnsPerPeer
+		if t.MaxIdleConnsPerHost <= 0 {
+			t.MaxIdleConnsPerHost = defaultMaxIdleConnsPerPeer
+		}
+		// 总的空闲连接数不限制，由每个节点的上限约束
+		t.MaxIdleConns = 0
+		p.transport = t
+	}
+	return p
 }
 
 func (p *HTTPPool) Log(format string, v ...interface{}) {
@@ -66,6 +157,19 @@ func (p *HTTPPool) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	p.Log("%s %s", r.Method, r.URL.Path)
 
+	switch r.URL.Path[len(p.basePath):] {
+	case statsPath:
+		p.serveStats(w)
+		return
+	case peersPath:
+		p.servePeers(w)
+		return
+	}
+	if rest := r.URL.Path[len(p.basePath):]; strings.HasPrefix(rest, batchPath) {
+		p.serveBatch(w, r, rest[len(batchPath):])
+		return
+	}
+
 	parts := strings.SplitN(r.URL.Path[len(p.basePath):], "/", 2)
 	if len(parts) != 2 {
 		http.Error(w, "bad request", http.StatusBadRequest)
@@ -80,10 +184,42 @@ func (p *HTTPPool) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "no such group: "+groupName, http.StatusNotFound)
 		return
 	}
+	group.Stats.ServerRequests.Add(1)
+
+	ctx, cancel := requestContext(r)
+	defer cancel()
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+	case http.MethodPut:
+		p.serveSet(ctx, w, r, group, key)
+		return
+	case http.MethodDelete:
+		// 发起方已经负责广播，本节点只在本地删除
+		group.RemoveContext(WithNoForward(ctx), key)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
 
-	view, err := group.Get(key)
+	atomic.AddInt64(&p.inflight, 1)
+	view, err := group.GetContext(ctx, key)
+	atomic.AddInt64(&p.inflight, -1)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, ErrNotFound):
+			w.Header().Set(notFoundHeader, "1")
+			status = http.StatusNotFound
+		case errors.Is(err, context.DeadlineExceeded):
+			status = http.StatusGatewayTimeout
+		default:
+			w.Header().Set(loadErrorHeader, "1")
+		}
+		http.Error(w, err.Error(), status)
 		return
 	}
 
@@ -91,26 +227,418 @@ func (p *HTTPPool) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Write(view.ByteSlice())
 }
 
+// serveSet 处理 PUT <basePath><group>/<key>：把请求体作为 value 写入本节点的 mainCache
+func (p *HTTPPool) serveSet(ctx context.Context, w http.ResponseWriter, r *http.Request, group *Group, key string) {
+	value, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "reading request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	// 请求方已按哈希环选中本节点，不再转发
+	if err := group.SetContext(WithNoForward(ctx), key, value); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// requestContext 根据请求头构造处理请求用的 ctx：
+// 带了 noForwardHeader 则只在本地加载；带了超时时间就在本节点上继续遵守它
+func requestContext(r *http.Request) (context.Context, context.CancelFunc) {
+	ctx := r.Context()
+	if r.Header.Get(noForwardHeader) != "" {
+		ctx = WithNoForward(ctx)
+	}
+	if ms, err := strconv.ParseInt(r.Header.Get(timeoutHeader), 10, 64); err == nil {
+		return context.WithTimeout(ctx, time.Duration(ms)*time.Millisecond)
+	}
+	return context.WithCancel(ctx)
+}
+
+// batchRequest 和 batchResponse 是批量获取的请求体和响应体，
+// 值按 encoding/json 的规则编码为 base64
+type batchRequest struct {
+	Keys []string `json:"keys"`
+}
+
+type batchResponse struct {
+	Values map[string][]byte `json:"values"`
+	Errors map[string]string `json:"errors,omitempty"`
+	// NotFound 列出 Errors 中因 ErrNotFound 失败的 key
+	NotFound []string `json:"not_found,omitempty"`
+}
+
+// serveBatch 处理批量获取：对 group 调用 GetManyContext，每个 key 的错误放在 errors 中返回
+func (p *HTTPPool) serveBatch(w http.ResponseWriter, r *http.Request, groupName string) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	group := GetGroup(groupName)
+	if group == nil {
+		http.Error(w, "no such group: "+groupName, http.StatusNotFound)
+		return
+	}
+	var req batchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	group.Stats.ServerRequests.Add(1)
+
+	ctx, cancel := requestContext(r)
+	defer cancel()
+
+	atomic.AddInt64(&p.inflight, 1)
+	values, errs := group.GetManyContext(ctx, req.Keys)
+	atomic.AddInt64(&p.inflight, -1)
+	res := batchResponse{Values: make(map[string][]byte, len(values))}
+	for key, v := range values {
+		res.Values[key] = v.b
+	}
+	if len(errs) > 0 {
+		res.Errors = make(map[string]string, len(errs))
+		for key, err := range errs {
+			res.Errors[key] = err.Error()
+			if errors.Is(err, ErrNotFound) {
+				res.NotFound = append(res.NotFound, key)
+			}
+		}
+	}
+	body, err := json.Marshal(res)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+// serveStats 以 JSON 返回所有 Group 的统计信息：{"<group>": {"stats": ..., "main_cache": ..., "hot_cache": ...}}
+func (p *HTTPPool) serveStats(w http.ResponseWriter) {
+	body, err := json.Marshal(allGroupStats())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+// servePeers 以 JSON 返回每个远程节点的熔断器状态：{"<peer>": {"state": "closed", ...}}
+func (p *HTTPPool) servePeers(w http.ResponseWriter) {
+	body, err := json.Marshal(p.PeerStats())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
 // NEW:
 // Set 接收一组"远程节点地址 peers"，构造一致性哈希环（p.peers）
-// 并为每个节点初始化 httpGetter 客户端
+// 并为每个节点初始化 httpGetter 客户端；会丢弃之前的所有节点
 //   - peers 要加入到缓存集群中的真实节点的地址列表
 //     写操作
 func (p *HTTPPool) Set(peers ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
 	// 1. 构造空的哈希环
-	p.peers = consistenthash.New(defaultReplicas, nil)
-	// 2. 将传入的真实节点地址添加到哈希环中（内部会为每个地址生成多个虚拟节点并排序）
+	p.peers = p.makePicker()
+	p.weights = nil
+	// 2. 为后续远程调用准备 map：键是节点地址，值是该节点的 httpGetter 客户端
+	p.httpGetters = make(map[string]*httpGetter, len(peers))
+	// 3. 把节点加入哈希环，并为每个节点构造 httpGetter
+	p.addPeersLocked(peers...)
+}
+
+// AddPeers 在运行时向集群加入节点，已存在的节点会被忽略。
+// 哈希环和 httpGetters 在同一把锁下修改，PickPeer 不会看到不一致的中间状态
+func (p *HTTPPool) AddPeers(peers ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.peers == nil {
+		p.peers = p.makePicker()
+		p.httpGetters = make(map[string]*httpGetter, len(peers))
+	}
+	p.addPeersLocked(peers...)
+}
+
+// AddWeightedPeers 在运行时向集群加入带权重的节点（节点地址 -> 权重），
+// 权重越大分到的 key 越多，例如按节点内存大小设置；已存在的节点会被忽略
+func (p *HTTPPool) AddWeightedPeers(peers map[string]int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.peers == nil {
+		p.peers = p.makePicker()
+		p.httpGetters = make(map[string]*httpGetter, len(peers))
+	}
+	for peer, weight := range peers {
+		if _, ok := p.httpGetters[peer]; !ok {
+			if p.weights == nil {
+				p.weights = make(map[string]int)
+			}
+			p.weights[peer] = weight
+		}
+		p.addWeightedLocked(peer, weight)
+		p.addGetterLocked(peer)
+	}
+}
+
+// addWeightedLocked 按权重把节点加入放置策略，调用方需持有 p.mu
+func (p *HTTPPool) addWeightedLocked(peer string, weight int) {
+	if wp, ok := p.peers.(consistenthash.WeightedPicker); ok {
+		wp.AddWeighted(peer, weight)
+	} else {
+		// 放置策略不支持权重（如 Jump），退化为等权重
+		p.peers.Add(peer)
+	}
+}
+
+// SetPicker 替换放置策略，例如 consistenthash.NewRendezvous、NewJump 或 NewMaglev，
+// 已有节点迁移到新的策略上，AddWeightedPeers 加入的节点沿用原来的权重，其余节点等权重。newPicker 为 nil 时恢复默认的 CRC32 一致性哈希环。
+// 故障转移、熔断绕行和对冲需要策略实现 consistenthash.ReplicaPicker，否则不生效
+func (p *HTTPPool) SetPicker(newPicker func() consistenthash.Picker) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.newPicker = newPicker
+	if p.peers == nil {
+		return
+	}
+	members := p.peers.Members()
+	p.peers = p.makePicker()
+	for _, peer := range members {
+		if weight, ok := p.weights[peer]; ok {
+			p.addWeightedLocked(peer, weight)
+		} else {
+			p.peers.Add(peer)
+		}
+	}
+}
+
+// makePicker 创建一个空的放置策略
+func (p *HTTPPool) makePicker() consistenthash.Picker {
+	if p.newPicker != nil {
+		return p.newPicker()
+	}
+	return consistenthash.New(p.replicas, p.hashFn)
+}
+
+// RemovePeers 在运行时把节点移出集群，只有原本由这些节点负责的 key 会被重新分配
+func (p *HTTPPool) RemovePeers(peers ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.peers == nil {
+		return
+	}
+	p.peers.Remove(peers...)
+	for _, peer := range peers {
+		delete(p.httpGetters, peer)
+		delete(p.weights, peer)
+	}
+}
+
+// addPeersLocked 把节点加入哈希环并创建 httpGetter，调用方需持有 p.mu
+//
+//	httpGetter.baseURL = 节点地址peer + basePath
+//	用于后续向其他远程节点发起缓存请求：baseURL/group/key
+func (p *HTTPPool) addPeersLocked(peers ...string) {
+	// 将真实节点地址添加到哈希环中（内部会为每个地址生成多个虚拟节点并排序）
 	p.peers.Add(peers...)
+	for _, peer := range peers {
+		p.addGetterLocked(peer)
+	}
+}
 
-	// 3. 为后续远程调用准备 map：键是节点地址，值是该节点的 httpGetter 客户端
-	p.httpGetters = make(map[string]*httpGetter, len(peers))
+// addGetterLocked 为节点创建 httpGetter（已存在则保留），调用方需持有 p.mu
+func (p *HTTPPool) addGetterLocked(peer string) {
+	if _, ok := p.httpGetters[peer]; !ok {
+		p.httpGetters[peer] = &httpGetter{
+			baseURL:   peer + p.basePath,
+			latency:   p.peerLatency(peer),
+			breaker:   p.newBreakerLocked(),
+			retry:     p.retry,
+			transport: p.transport,
+			timeout:   p.timeout,
+		}
+	}
+}
+
+// newBreakerLocked 按当前参数创建熔断器，未启用时返回 nil，调用方需持有 p.mu
+func (p *HTTPPool) newBreakerLocked() *circuitBreaker {
+	if p.breakerOpts == nil {
+		return nil
+	}
+	return newCircuitBreaker(*p.breakerOpts)
+}
+
+// SetCircuitBreaker 设置每个远程节点熔断器的参数，已有节点的熔断器会按新参数重建；
+// opts 为 nil 时关闭熔断。熔断打开的节点会被 PickPeer 直接跳过，
+// 请求转给环上的下一个节点或在本地加载，不必每次都等待 TCP 超时
+func (p *HTTPPool) SetCircuitBreaker(opts *CircuitBreakerOptions) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.breakerOpts = opts
+	for _, h := range p.httpGetters {
+		h.breaker = p.newBreakerLocked()
+	}
+}
+
+// SetRetryPolicy 设置远程请求的重试策略，对已有节点立即生效；rp 为 nil 时不重试。
+// 重试发生在同一个节点上，与换到下一个节点的 failover 互不影响
+func (p *HTTPPool) SetRetryPolicy(rp *RetryPolicy) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.retry = rp
+	for _, h := range p.httpGetters {
+		h.retry = rp
+	}
+}
+
+// SetHedging 启用对冲请求：发往负责节点的请求在其 p95 耗时内没有返回时，
+// 向环上的下一个节点再发一份，先返回的胜出，另一份被取消。opts 为 nil 时关闭。
+// 需要放置策略支持 consistenthash.ReplicaPicker
+func (p *HTTPPool) SetHedging(opts *HedgeOptions) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.hedge = opts
+}
+
+// hedgeLocked 在启用对冲时，把 primary 包装为同时会请求下一个可用节点的 hedgedGetter；
+// 没有合适的下一个节点时原样返回，调用方需持有 p.mu
+func (p *HTTPPool) hedgeLocked(key, primary string) PeerGetter {
+	h := p.httpGetters[primary]
+	rp, ok := p.peers.(consistenthash.ReplicaPicker)
+	if p.hedge == nil || !ok {
+		return h
+	}
+	for _, peer := range rp.GetN(key, len(p.httpGetters)) {
+		if peer == p.self {
+			break
+		}
+		if peer != primary && p.readyLocked(peer) {
+			return &hedgedGetter{
+				primary:   h,
+				secondary: p.httpGetters[peer],
+				delay:     p.hedge.delay(h.latency),
+				hedges:    &p.hedges,
+			}
+		}
+	}
+	return h
+}
+
+// PeerStats 是单个远程节点的状态
+type PeerStats struct {
+	State    string `json:"state"`    // 熔断器状态：closed、open、half-open
+	Requests int    `json:"requests"` // 当前统计窗口内的请求数
+	Failures int    `json:"failures"` // 当前统计窗口内的失败数
+	Inflight int64  `json:"inflight"` // 进行中的请求数
+}
+
+// PeerStats 返回每个远程节点（不含本节点）的状态
+func (p *HTTPPool) PeerStats() map[string]PeerStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	m := make(map[string]PeerStats, len(p.httpGetters))
+	for peer, h := range p.httpGetters {
+		if peer == p.self {
+			continue
+		}
+		st := PeerStats{State: breakerClosed.String(), Inflight: atomic.LoadInt64(&h.inflight)}
+		if h.breaker != nil {
+			state, requests, failures := h.breaker.snapshot()
+			st.State, st.Requests, st.Failures = state.String(), requests, failures
+		}
+		m[peer] = st
+	}
+	return m
+}
+
+// peerLatency 返回 peer 对应的耗时直方图，不存在则新建
+func (p *HTTPPool) peerLatency(peer string) *histogram {
+	if p.latencies == nil {
+		p.latencies = make(map[string]*histogram)
+	}
+	h, ok := p.latencies[peer]
+	if !ok {
+		h = newHistogram()
+		p.latencies[peer] = h
+	}
+	return h
+}
+
+// writeMetrics 以 Prometheus 文本格式输出哈希环大小、每个远程节点的请求耗时和熔断状态
+func (p *HTTPPool) writeMetrics(w io.Writer) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP geecache_ring_peers Number of peers in the consistent hash ring.\n")
+	fmt.Fprintf(w, "# TYPE geecache_ring_peers gauge\n")
+	fmt.Fprintf(w, "geecache_ring_peers{self=\"%s\"} %d\n", escapeLabel(p.self), len(p.httpGetters))
 
-	// 3. 遍历所有真实节点地址，为每个地址构造一个httpGetter客户端
-	//     httpGetter.baseURL = 节点地址peer + basePath
-	//     用于后续向其他远程节点发起缓存请求：baseURL/group/key
+	peers := make([]string, 0, len(p.latencies))
+	for peer := range p.latencies {
+		peers = append(peers, peer)
+	}
+	sort.Strings(peers)
+
+	const name = "geecache_peer_request_duration_seconds"
+	fmt.Fprintf(w, "# HELP %s Latency of requests to remote peers.\n# TYPE %s histogram\n", name, name)
+	for _, peer := range peers {
+		p.latencies[peer].write(w, name, fmt.Sprintf("peer=\"%s\"", escapeLabel(peer)))
+	}
+	fmt.Fprintf(w, "# HELP geecache_peer_circuit_state Circuit breaker state of remote peers (0 closed, 1 open, 2 half-open).\n")
+	fmt.Fprintf(w, "# TYPE geecache_peer_circuit_state gauge\n")
+	peers = peers[:0]
+	for peer := range p.httpGetters {
+		if peer != p.self {
+			peers = append(peers, peer)
+		}
+	}
+	sort.Strings(peers)
 	for _, peer := range peers {
-		p.httpGetters[peer] = &httpGetter{baseURL: peer + p.basePath}
+		state := breakerClosed
+		if b := p.httpGetters[peer].breaker; b != nil {
+			state, _, _ = b.snapshot()
+		}
+		fmt.Fprintf(w, "geecache_peer_circuit_state{peer=\"%s\"} %d\n", escapeLabel(peer), state)
+	}
+	fmt.Fprintf(w, "# HELP geecache_peer_hedged_requests_total Number of hedged requests sent to a successor peer.\n")
+	fmt.Fprintf(w, "# TYPE geecache_peer_hedged_requests_total counter\n")
+	fmt.Fprintf(w, "geecache_peer_hedged_requests_total{self=\"%s\"} %d\n", escapeLabel(p.self), atomic.LoadInt64(&p.hedges))
+}
+
+// SetLoadBound 启用"有界负载的一致性哈希"：本节点发往某个远程节点的进行中请求数
+// 超过 (1+epsilon) 倍平均值时，PickPeer 顺时针跳到下一个节点，避免少数热点 key 压垮一个节点。
+// 本节点的负载是它正在处理的来自其他节点的请求数；绕行的请求带上 NoForward，接收方直接在本地加载。
+// epsilon <= 0 时关闭，回到普通的一致性哈希。只有支持 consistenthash.BoundedPicker 的放置策略生效
+func (p *HTTPPool) SetLoadBound(epsilon float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.loadBound = max(epsilon, 0)
+}
+
+// peerLoadLocked 返回本节点发往 peer 的进行中请求数；peer 是本节点时返回本节点正在处理的请求数。
+// 调用方需持有 p.mu
+func (p *HTTPPool) peerLoadLocked(peer string) int64 {
+	if peer == p.self {
+		return atomic.LoadInt64(&p.inflight)
 	}
+	if h, ok := p.httpGetters[peer]; ok {
+		return atomic.LoadInt64(&h.inflight)
+	}
+	return 0
 }
 
 // NEW:
@@ -127,17 +655,195 @@ func (p *HTTPPool) PickPeer(key string) (PeerGetter, bool) {
 	// 判断选出的 peer 是否有效且不是自己：
 	//		- peer == "" 哈希环上无节点
 	//		- peer == p.self 说明key落在自己负责的区间，不应向远程请求
-	if peer := p.peers.Get(key); peer != "" && peer != p.self {
+	peer, owner := p.routeLocked(key)
+	if peer != "" && peer != p.self {
 		// 记录日志，便于调试：表明此 key 被路由到远程节点 peer
 		p.Log("Pick peer %s", peer)
-		// 返回该 peer 对应的 HTTP 客户端（实现 PeerGetter），以及 true 标志
-		return p.httpGetters[peer], true
+		// 绕开了负责节点：让收到的节点直接在本地加载，否则它会按哈希环把请求再转回负责节点
+		if peer != owner {
+			return divertedGetter{p.httpGetters[peer]}, true
+		}
+		// 返回该 peer 对应的 HTTP 客户端（实现 PeerGetter），以及 true 标志；启用对冲时包装一层
+		return p.hedgeLocked(key, peer), true
 	}
 	// 2. 如果没有选出远程节点，或选中自己，则返回(nil,false)
 	//		上层会检测到 false 并回退到本地处理逻辑
 	return nil, false
 }
 
+// PickBatchPeer 与 PickPeer 选出同一个节点，但不做对冲包装，
+// 发往同一节点的 key 得到同一个 peer，供 Group.GetMany 合并批量请求（实现了 BatchPeerPicker 接口）
+func (p *HTTPPool) PickBatchPeer(key string) (PeerGetter, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	peer, owner := p.routeLocked(key)
+	if peer == "" || peer == p.self {
+		return nil, false
+	}
+	p.Log("Pick peer %s", peer)
+	if peer != owner {
+		return divertedGetter{p.httpGetters[peer]}, true
+	}
+	return p.httpGetters[peer], true
+}
+
+// PickOwner 返回 key 在哈希环上的负责节点，不考虑有界负载和熔断（实现了 OwnerPicker 接口）。
+// 负责节点熔断时返回的 peer 的 Set 直接失败
+func (p *HTTPPool) PickOwner(key string) (PeerGetter, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.peers == nil {
+		return nil, false
+	}
+	owner := p.peers.Get(key)
+	if owner == "" || owner == p.self {
+		return nil, false
+	}
+	return p.httpGetters[owner], true
+}
+
+// routeLocked 返回 key 应发往的节点 peer 以及它在哈希环上的负责节点 owner：
+// 启用有界负载时可能绕开过载的负责节点，负责节点已熔断时沿环找下一个可用的节点。
+// peer 为空或是本节点时应在本地处理
+func (p *HTTPPool) routeLocked(key string) (peer, owner string) {
+	if p.peers == nil {
+		return "", ""
+	}
+	owner = p.peers.Get(key)
+	peer = owner
+	if bp, ok := p.peers.(consistenthash.BoundedPicker); ok && p.loadBound > 0 {
+		peer = bp.GetBounded(key, p.loadBound, p.peerLoadLocked)
+	}
+	if peer != "" && peer != p.self && !p.readyLocked(peer) {
+		peer = p.nextReadyLocked(key)
+	}
+	return peer, owner
+}
+
+// divertedGetter 包装发往非负责节点（因负载上限或熔断而绕行）的请求，
+// 请求都带上 NoForward，收到的节点直接在本地加载
+type divertedGetter struct {
+	h *httpGetter
+}
+
+func (d divertedGetter) Get(group string, key string) ([]byte, error) {
+	return d.GetContext(context.Background(), group, key)
+}
+
+func (d divertedGetter) GetContext(ctx context.Context, group string, key string) ([]byte, error) {
+	return d.h.GetContext(WithNoForward(ctx), group, key)
+}
+
+func (d divertedGetter) GetMany(ctx context.Context, group string, keys []string) (map[string][]byte, map[string]error) {
+	return d.h.GetMany(WithNoForward(ctx), group, keys)
+}
+
+var (
+	_ ContextPeerGetter = divertedGetter{}
+	_ BatchPeerGetter   = divertedGetter{}
+)
+
+// ListPeers 返回所有远程节点（不含本节点）的 PeerGetter，供 Group.Remove 广播删除（实现了 PeerLister 接口）
+func (p *HTTPPool) ListPeers() map[string]PeerGetter {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	peers := make(map[string]PeerGetter, len(p.httpGetters))
+	for peer, h := range p.httpGetters {
+		if peer != p.self {
+			peers[peer] = h
+		}
+	}
+	return peers
+}
+
+// readyLocked 判断 peer 的熔断器是否放行请求，调用方需持有 p.mu
+func (p *HTTPPool) readyLocked(peer string) bool {
+	h, ok := p.httpGetters[peer]
+	return ok && (h.breaker == nil || h.breaker.ready())
+}
+
+// nextReadyLocked 沿环返回 key 的第一个未熔断的负责节点；
+// 先遇到本节点或放置策略不支持 ReplicaPicker 时返回 ""
+func (p *HTTPPool) nextReadyLocked(key string) string {
+	rp, ok := p.peers.(consistenthash.ReplicaPicker)
+	if !ok {
+		return ""
+	}
+	for _, peer := range rp.GetN(key, len(p.httpGetters)) {
+		if peer == p.self {
+			return ""
+		}
+		if p.readyLocked(peer) {
+			return peer
+		}
+	}
+	return ""
+}
+
+// SetFailoverPeers 设置 PickPeers 最多返回的远程节点个数：
+// 负责节点请求失败时，Group 依次尝试哈希环上的接替节点，而不是每个节点都直接回源。
+// n <= 1 时不做故障转移
+func (p *HTTPPool) SetFailoverPeers(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failover = max(n, 1)
+}
+
+// PickPeers 返回 key 在环上依次的负责节点，最多 failover 个（实现 ReplicaPicker 接口）。
+// 遇到本节点时截断：本节点之后的接替者没有意义，直接在本地加载即可；熔断的节点会被跳过。
+// 除负责节点外的节点都包装为 divertedGetter：负责节点熔断时，排在第一个的接替节点也不会把请求转发回去。
+// 放置策略不支持 consistenthash.ReplicaPicker 或启用了有界负载时，退化为 PickPeer 的结果
+func (p *HTTPPool) PickPeers(key string) []PeerGetter {
+	p.mu.Lock()
+	rp, ok := p.peers.(consistenthash.ReplicaPicker)
+	if !ok || p.loadBound > 0 || p.failover <= 1 {
+		p.mu.Unlock()
+		if peer, ok := p.PickPeer(key); ok {
+			return []PeerGetter{peer}
+		}
+		return nil
+	}
+	defer p.mu.Unlock()
+
+	// 跳过熔断的节点，凑够 failover 个可用节点
+	var owner string
+	var peers []string
+	for i, peer := range rp.GetN(key, len(p.httpGetters)) {
+		if i == 0 {
+			owner = peer
+		}
+		if peer == p.self || len(peers) == p.failover {
+			break
+		}
+		if !p.readyLocked(peer) {
+			continue
+		}
+		p.Log("Pick peer %s", peer)
+		peers = append(peers, peer)
+	}
+	if len(peers) == 0 {
+		return nil
+	}
+	getters := make([]PeerGetter, len(peers))
+	for i, peer := range peers {
+		if peer == owner {
+			getters[i] = p.httpGetters[peer]
+		} else {
+			getters[i] = divertedGetter{p.httpGetters[peer]}
+		}
+	}
+	// 启用对冲时，第一个节点的请求会同时对冲到第二个节点，第二个节点不再单独尝试
+	if p.hedge != nil && len(peers) > 1 {
+		first, second := p.httpGetters[peers[0]], p.httpGetters[peers[1]]
+		hedged := &hedgedGetter{primary: first, secondary: second, diverted: peers[0] != owner, delay: p.hedge.delay(first.latency), hedges: &p.hedges}
+		getters = append([]PeerGetter{hedged}, getters[2:]...)
+	}
+	return getters
+}
+
 // NEW:
 // 编译期断言：HTTPPool 必须实现 PeerPicker 接口
 // 将一个类型为 *HTTPPool 的“零值指针”赋给接口类型 PeerPicker 的匿名变量 _。
@@ -154,6 +860,10 @@ func (p *HTTPPool) PickPeer(key string) (PeerGetter, bool) {
 // 3. 零开销
 // 因为赋给了空白标识符 _，不会产生任何运行时开销或存储。
 var _ PeerPicker = (*HTTPPool)(nil)
+var _ ReplicaPicker = (*HTTPPool)(nil)
+var _ PeerLister = (*HTTPPool)(nil)
+var _ BatchPeerPicker = (*HTTPPool)(nil)
+var _ OwnerPicker = (*HTTPPool)(nil)
 
 // NEW: HTTP客户端，负责对远程节点发起请求
 // httpGetter 实现了 PeerGetter 接口，负责通过 HTTP 向特定远程节点获取缓存
@@ -164,16 +874,59 @@ type httpGetter struct {
 	//		httpGetter 会使用这个 baseURL，并拼接上 group 名称和 key，
 	//		构造出完整的请求 URL: http://localhost:8001/geecache/<group>/<key>），
 	//		然后向这个 URL 发起 HTTP GET 请求。
-	baseURL string // 不同的远程节点的 baseURL 的区别在于它们指向了不同的网络地址和端口
+	baseURL   string            // 不同的远程节点的 baseURL 的区别在于它们指向了不同的网络地址和端口
+	latency   *histogram        // 记录请求耗时，可以为 nil
+	inflight  int64             // 进行中的请求数，原子访问，供有界负载选点使用
+	breaker   *circuitBreaker   // 熔断器，nil 表示不熔断
+	transport http.RoundTripper // nil 时使用 http.DefaultTransport
+	retry     *RetryPolicy      // 重试策略，nil 表示不重试
+	timeout   time.Duration     // 单次请求的超时时间，0 表示不限制
 }
 
 // NEW:
-// Get方法 客户端向远程节点发起 HTTP 请求，获取特定缓存组中某个键对应的值（实现了PeerGetter 接口）（发起请求/读取相应体）
-// 1. URL 组装：h.baseURL 已含 /_geecache/ 前缀，随后拼接转义后的 group 和 key，形成完整路径。
-// 2. 发起请求：直接调用 http.Get(u)。若网络或地址错误，立即失败返回。
-// 3. 状态校验：仅在远程返回 HTTP 200 时继续；否则将状态码封装为错误。
-// 4. 读取响应：用 io.ReadAll 获取所有响应体字节，并返回给上层。
+// Get方法 客户端向远程节点发起 HTTP 请求，获取特定缓存组中某个键对应的值（实现了PeerGetter 接口）
 func (h *httpGetter) Get(group string, key string) ([]byte, error) {
+	return h.GetContext(context.Background(), group, key)
+}
+
+// GetContext 发起请求/读取响应体（实现了 ContextPeerGetter 接口），配置了 RetryPolicy 时按策略重试。
+// 单次请求由 fetch 完成：
+//  1. URL 组装：h.baseURL 已含 /_geecache/ 前缀，随后拼接转义后的 group 和 key，形成完整路径。
+//  2. 发起请求：用 http.NewRequestWithContext 构造请求，ctx 取消时请求随之中断；
+//     若 ctx 带有截止时间，把剩余时长写入 timeoutHeader，让远程节点也遵守它。
+//  3. 状态校验：仅在远程返回 HTTP 200 时继续；否则将状态码封装为错误。
+//  4. 读取响应：用 io.ReadAll 获取所有响应体字节，并返回给上层。
+func (h *httpGetter) GetContext(ctx context.Context, group string, key string) ([]byte, error) {
+	if h.retry == nil {
+		return h.fetch(ctx, group, key)
+	}
+	return h.retry.do(ctx, func() ([]byte, error) { return h.fetch(ctx, group, key) })
+}
+
+// fetch 向远程节点发起一次请求，不重试
+func (h *httpGetter) fetch(ctx context.Context, group string, key string) (value []byte, err error) {
+	// 熔断器打开时直接失败，不等待网络超时
+	if h.breaker != nil {
+		gen, ok := h.breaker.allow()
+		if !ok {
+			return nil, errCircuitOpen
+		}
+		// 传入调用方的 ctx：下面设置的单次超时不算调用方取消
+		defer func(ctx context.Context) {
+			if err != nil && ctx.Err() != nil {
+				h.breaker.abort(gen)
+				return
+			}
+			h.breaker.record(gen, !peerFailed(err))
+		}(ctx)
+	}
+	// 单次请求超时算作节点故障，会被熔断器记录
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+
 	// 1. 构造请求 URL (h.baseURL 已含 /_geecache/ 前缀，随后拼接转义后的 group 和 key，形成完整路径。)
 	//    h.baseURL 形如 "http://<peerAddr>/_geecache/"
 	//    对 group 和 key 做 URL 转义，防止特殊字符破坏路径
@@ -184,19 +937,30 @@ func (h *httpGetter) Get(group string, key string) ([]byte, error) {
 		url.QueryEscape(key),
 	)
 
-	// 2. 发起 HTTP GET 请求
-	//    这里使用 Go 标准库 http.Get，简洁但无连接复用优化
-	res, err := http.Get(u)
+	// 2. 构造带 ctx 的 HTTP GET 请求并发出
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := h.do(req)
 	if err != nil {
-		// 网络错误或无法连接时直接返回
+		// 网络错误、无法连接或 ctx 结束时直接返回
 		return nil, err
 	}
 	// 确保在函数返回前关闭响应体，防止连接泄漏
 	defer res.Body.Close()
 
-	// 3. 检查 HTTP 状态码，非 200 视为失败
+	// 3. 检查 HTTP 状态码，非 200 视为失败；带 notFoundHeader 的 404 表示 key 不存在
+	if res.StatusCode == http.StatusNotFound && res.Header.Get(notFoundHeader) != "" {
+		msg, _ := io.ReadAll(res.Body)
+		return nil, &NotFoundError{Msg: strings.TrimSuffix(string(msg), "\n")}
+	}
+	if res.StatusCode == http.StatusInternalServerError && res.Header.Get(loadErrorHeader) != "" {
+		msg, _ := io.ReadAll(res.Body)
+		return nil, &RemoteLoadError{Msg: strings.TrimSuffix(string(msg), "\n")}
+	}
 	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("server returned: %v", res.Status)
+		return nil, &statusError{code: res.StatusCode, status: res.Status}
 	}
 
 	// 4. 读取全部响应数据
@@ -210,5 +974,187 @@ func (h *httpGetter) Get(group string, key string) ([]byte, error) {
 	return bytes, nil
 }
 
+// do 设置节点间的请求头后发出请求，并记录进行中的请求数和耗时
+func (h *httpGetter) do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if NoForward(ctx) {
+		req.Header.Set(noForwardHeader, "1")
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		req.Header.Set(timeoutHeader, strconv.FormatInt(time.Until(deadline).Milliseconds(), 10))
+	}
+
+	atomic.AddInt64(&h.inflight, 1)
+	defer atomic.AddInt64(&h.inflight, -1)
+	if h.latency != nil {
+		defer func(start time.Time) { h.latency.observe(time.Since(start)) }(time.Now())
+	}
+	transport := h.transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return (&http.Client{Transport: transport}).Do(req)
+}
+
+// Set 通过 PUT 把 value 写入远程节点的 mainCache（实现了 PeerSetter 接口）
+func (h *httpGetter) Set(ctx context.Context, group string, key string, value []byte) error {
+	// 写入不计入熔断器的统计，但节点已熔断时直接失败，不等待网络超时
+	if h.breaker != nil && !h.breaker.ready() {
+		return errCircuitOpen
+	}
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+	u := h.baseURL + url.QueryEscape(group) + "/" + url.QueryEscape(key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u, bytes.NewReader(value))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	res, err := h.do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
+		return &statusError{code: res.StatusCode, status: res.Status}
+	}
+	return nil
+}
+
+// Remove 通过 DELETE 删除远程节点 mainCache 和 hotCache 中的 key（实现了 PeerRemover 接口）。
+// 与 fetch 一样受熔断器保护和单次超时限制，广播删除时宕机的节点不会拖住调用方
+func (h *httpGetter) Remove(ctx context.Context, group string, key string) (err error) {
+	if h.breaker != nil {
+		gen, ok := h.breaker.allow()
+		if !ok {
+			return errCircuitOpen
+		}
+		defer func(ctx context.Context) {
+			if err != nil && ctx.Err() != nil {
+				h.breaker.abort(gen)
+				return
+			}
+			h.breaker.record(gen, !peerFailed(err))
+		}(ctx)
+	}
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+	u := h.baseURL + url.QueryEscape(group) + "/" + url.QueryEscape(key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, u, nil)
+	if err != nil {
+		return err
+	}
+	res, err := h.do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
+		return &statusError{code: res.StatusCode, status: res.Status}
+	}
+	return nil
+}
+
+// GetMany 通过批量接口一次拉取多个 key（实现了 BatchPeerGetter 接口）。
+// 请求整体失败时，每个 key 都返回同一个错误
+func (h *httpGetter) GetMany(ctx context.Context, group string, keys []string) (map[string][]byte, map[string]error) {
+	res, err := h.getMany(ctx, group, keys)
+	if err != nil {
+		errs := make(map[string]error, len(keys))
+		for _, key := range keys {
+			errs[key] = err
+		}
+		return nil, errs
+	}
+	errs := make(map[string]error, len(res.Errors))
+	// 响应中的每个错误都是远程节点自己加载失败
+	for key, msg := range res.Errors {
+		errs[key] = &RemoteLoadError{Msg: msg}
+	}
+	for _, key := range res.NotFound {
+		errs[key] = &NotFoundError{Msg: res.Errors[key]}
+	}
+	return res.Values, errs
+}
+
+// getMany 发出一次批量请求，与 fetch 一样受熔断器保护和单次超时限制
+func (h *httpGetter) getMany(ctx context.Context, group string, keys []string) (res *batchResponse, err error) {
+	if h.breaker != nil {
+		gen, ok := h.breaker.allow()
+		if !ok {
+			return nil, errCircuitOpen
+		}
+		defer func(ctx context.Context) {
+			if err != nil && ctx.Err() != nil {
+				h.breaker.abort(gen)
+				return
+			}
+			h.breaker.record(gen, !peerFailed(err))
+		}(ctx)
+	}
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+
+	body, err := json.Marshal(batchRequest{Keys: keys})
+	if err != nil {
+		return nil, err
+	}
+	u := h.baseURL + batchPath + url.QueryEscape(group)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	r, err := h.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+	if r.StatusCode != http.StatusOK {
+		return nil, &statusError{code: r.StatusCode, status: r.Status}
+	}
+	res = new(batchResponse)
+	if err := json.NewDecoder(r.Body).Decode(res); err != nil {
+		return nil, fmt.Errorf("decoding batch response: %v", err)
+	}
+	return res, nil
+}
+
+// statusError 表示远程节点返回了非 200 的状态码
+type statusError struct {
+	code   int
+	status string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("server returned: %v", e.status)
+}
+
+// peerFailed 判断一次请求的错误是否说明远程节点不健康：只有网络错误、超时和 503 算节点故障；
+// 4xx（如 key 不存在）和远程 Getter 的加载失败（ErrRemoteLoad 或其他 500）说明节点本身工作正常
+func peerFailed(err error) bool {
+	if err == nil || errors.Is(err, ErrNotFound) || errors.Is(err, ErrRemoteLoad) {
+		return false
+	}
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.code == http.StatusServiceUnavailable || se.code == http.StatusGatewayTimeout
+	}
+	return true
+}
+
 // NEW: 编译期断言：httpGetter 必须实现 PeerGetter 接口
 var _ PeerGetter = (*httpGetter)(nil)
+var _ ContextPeerGetter = (*httpGetter)(nil)
+var _ BatchPeerGetter = (*httpGetter)(nil)
+var _ PeerSetter = (*httpGetter)(nil)
+var _ PeerRemover = (*httpGetter)(nil)
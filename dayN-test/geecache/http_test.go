@@ -0,0 +1,897 @@
+package geecache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"geecache/consistenthash"
+	"geecache/singleflight"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestHTTPGetterDeadline 客户端 ctx 的截止时间应通过请求头传递到远程节点，
+// 远程节点的 Getter 能感知到这个截止时间
+func TestHTTPGetterDeadline(t *testing.T) {
+	gotDeadline := make(chan bool, 1)
+	NewGroup("http-deadline", 2<<10, ContextGetterFunc(
+		func(ctx context.Context, key string) ([]byte, error) {
+			_, ok := ctx.Deadline()
+			gotDeadline <- ok
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}))
+
+	srv := httptest.NewServer(NewHTTPPool("self"))
+	defer srv.Close()
+
+	getter := &httpGetter{baseURL: srv.URL + defaultBasePath}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if _, err := getter.GetContext(ctx, "http-deadline", "Tom"); err == nil {
+		t.Fatal("expect error after deadline")
+	}
+	if !<-gotDeadline {
+		t.Fatal("remote getter did not receive a deadline")
+	}
+}
+
+func TestGetContextCanceled(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	gee := NewGroup("ctx-canceled", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) {
+			<-release
+			return []byte("v"), nil
+		}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := gee.GetContext(ctx, "Tom"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expect DeadlineExceeded, got %v", err)
+	}
+}
+
+// TestGetContextSharedLoad 合并的加载不随第一个调用者取消：A 放弃后 B 仍拿到值
+func TestGetContextSharedLoad(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var loads int32
+	gee := newLocalGroup("ctx-shared", ContextGetterFunc(
+		func(ctx context.Context, key string) ([]byte, error) {
+			if atomic.AddInt32(&loads, 1) == 1 {
+				close(started)
+			}
+			select {
+			case <-release:
+				return []byte("v"), ctx.Err()
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}))
+
+	ctxA, cancelA := context.WithCancel(context.Background())
+	errA := make(chan error, 1)
+	go func() {
+		_, err := gee.GetContext(ctxA, "Tom")
+		errA <- err
+	}()
+	<-started
+
+	resB := make(chan ByteView, 1)
+	errB := make(chan error, 1)
+	go func() {
+		v, err := gee.GetContext(context.Background(), "Tom")
+		resB <- v
+		errB <- err
+	}()
+	// 给 B 时间加入同一次加载；即使 B 晚于取消才加入，加载也仍在进行
+	time.Sleep(20 * time.Millisecond)
+
+	cancelA()
+	if err := <-errA; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expect Canceled for A, got %v", err)
+	}
+	close(release)
+	if v, err := <-resB, <-errB; err != nil || v.String() != "v" {
+		t.Fatalf("B should get the value after A canceled, got %q %v", v.String(), err)
+	}
+	if loads != 1 {
+		t.Fatalf("expect one shared load, got %d", loads)
+	}
+}
+
+// TestHotCache 从远程节点拉取的值应写入 hotCache，再次 Get 时不再访问远程节点
+func TestHotCache(t *testing.T) {
+	defer func(p int) { hotCachePercent = p }(hotCachePercent)
+	hotCachePercent = 100
+
+	var remoteLoads int32
+	NewGroup("hot-remote", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) {
+			atomic.AddInt32(&remoteLoads, 1)
+			return []byte(db[key]), nil
+		}))
+	// 远程节点只挂载了 HTTPPool，本地节点的 Group 使用同名命名空间
+	srv := httptest.NewServer(NewHTTPPool("remote"))
+	defer srv.Close()
+
+	local := newLocalGroup("hot-remote", GetterFunc(func(key string) ([]byte, error) {
+		return nil, errors.New("should not load locally")
+	}))
+	local.RegisterPeers(fixedPicker{&httpGetter{baseURL: srv.URL + defaultBasePath}})
+
+	for i := 0; i < 3; i++ {
+		if view, err := local.Get("Tom"); err != nil || view.String() != "630" {
+			t.Fatalf("failed to get Tom from peer: %v %v", view, err)
+		}
+	}
+	if _, ok := local.hotCache.get("Tom"); !ok {
+		t.Fatal("peer value should be stored in hotCache")
+	}
+	if _, ok := local.mainCache.get("Tom"); ok {
+		t.Fatal("peer value should not be stored in mainCache")
+	}
+	if n := atomic.LoadInt32(&remoteLoads); n != 1 {
+		t.Fatalf("remote getter called %d times, want 1", n)
+	}
+}
+
+// ttlGetter 是给出固定有效期的 TTLPeerGetter，统计被调用的次数
+type ttlGetter struct {
+	ttl   time.Duration
+	loads int32
+}
+
+func (g *ttlGetter) Get(group string, key string) ([]byte, error) {
+	v, _, err := g.GetWithTTL(context.Background(), group, key)
+	return v, err
+}
+
+func (g *ttlGetter) GetWithTTL(ctx context.Context, group string, key string) ([]byte, time.Duration, error) {
+	atomic.AddInt32(&g.loads, 1)
+	return []byte("v"), g.ttl, nil
+}
+
+// TestHotCacheTTL hotCache 中的副本不超过远程节点给出的有效期
+func TestHotCacheTTL(t *testing.T) {
+	defer func(p int) { hotCachePercent = p }(hotCachePercent)
+	hotCachePercent = 100
+
+	peer := &ttlGetter{ttl: time.Second}
+	local := newLocalGroup("hot-ttl", GetterFunc(func(key string) ([]byte, error) {
+		return nil, errors.New("should not load locally")
+	}))
+	var now atomic.Int64 // 假时钟，UnixNano
+	local.hotCache.now = func() time.Time { return time.Unix(0, now.Load()) }
+	local.RegisterPeers(fixedPicker{peer})
+
+	local.Get("Tom")
+	local.Get("Tom")
+	if n := atomic.LoadInt32(&peer.loads); n != 1 {
+		t.Fatalf("expect a hotCache hit within the ttl, loaded %d times", n)
+	}
+	now.Add(int64(time.Second))
+	local.Get("Tom")
+	if n := atomic.LoadInt32(&peer.loads); n != 2 {
+		t.Fatalf("expect a reload after the remote ttl, loaded %d times", n)
+	}
+}
+
+// newLocalGroup 创建一个不注册到全局 groups 的 Group，模拟同一进程中的另一个节点：
+// 它和通过 HTTPPool 提供服务的全局同名 Group 互不共享缓存
+func newLocalGroup(name string, getter Getter) *Group {
+	return &Group{
+		name:      name,
+		getter:    toContextGetter(getter),
+		mainCache: cache{cacheBytes: 2 << 10},
+		hotCache:  cache{cacheBytes: 2 << 10},
+		loader:    &singleflight.Group{},
+	}
+}
+
+// TestFailover 负责节点宕机时，请求转给环上的下一个节点，并且它不再转发回去
+func TestFailover(t *testing.T) {
+	var remoteLoads int32
+	remote := NewGroup("failover", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) {
+			atomic.AddInt32(&remoteLoads, 1)
+			return []byte("v" + key), nil
+		}))
+	// 接替节点不应再按哈希环转发
+	remote.RegisterPeers(fixedPicker{nil})
+
+	alive := httptest.NewServer(NewHTTPPool("alive"))
+	defer alive.Close()
+	dead := httptest.NewServer(nil)
+	dead.Close()
+
+	pool := NewHTTPPool("http://self")
+	pool.Set("http://self", dead.URL, alive.URL)
+
+	// 找一个以 dead、alive 为前两个负责节点的 key
+	var key string
+	for i := 0; key == ""; i++ {
+		k := strconv.Itoa(i)
+		if owners := pool.peers.(consistenthash.ReplicaPicker).GetN(k, 2); owners[0] == dead.URL && owners[1] == alive.URL {
+			key = k
+		}
+	}
+
+	local := newLocalGroup("failover", GetterFunc(func(key string) ([]byte, error) {
+		return nil, errors.New("should not load locally")
+	}))
+	local.RegisterPeers(pool)
+	if view, err := local.Get(key); err != nil || view.String() != "v"+key {
+		t.Fatalf("failed to get %s via failover: %v %v", key, view, err)
+	}
+	if n := atomic.LoadInt32(&remoteLoads); n != 1 {
+		t.Fatalf("successor getter called %d times, want 1", n)
+	}
+	if local.Stats.PeerErrors.Get() != 1 || local.Stats.PeerLoads.Get() != 1 {
+		t.Fatalf("unexpected stats: peer errors %d, peer loads %d", local.Stats.PeerErrors.Get(), local.Stats.PeerLoads.Get())
+	}
+}
+
+// TestFailoverOwnerOpen 负责节点熔断被跳过时，发往第一个接替节点的请求带上 noForwardHeader，
+// 启用对冲时也一样
+func TestFailoverOwnerOpen(t *testing.T) {
+	for _, hedge := range []bool{false, true} {
+		pool := NewHTTPPool("http://self")
+		pool.SetCircuitBreaker(&CircuitBreakerOptions{Window: time.Minute, MinRequests: 1, FailureRate: 1, Cooldown: time.Minute})
+		if hedge {
+			pool.SetHedging(&DefaultHedgeOptions)
+		}
+		pool.Set("http://a", "http://b", "http://c")
+		var key string
+		for i := 0; key == ""; i++ {
+			k := strconv.Itoa(i)
+			if owners := pool.peers.(consistenthash.ReplicaPicker).GetN(k, 2); owners[0] == "http://a" && owners[1] == "http://b" {
+				key = k
+			}
+		}
+		b := pool.httpGetters["http://a"].breaker
+		gen, _ := b.allow()
+		b.record(gen, false)
+
+		var mu sync.Mutex
+		headers := map[string]string{}
+		for _, peer := range []string{"http://a", "http://b", "http://c"} {
+			pool.httpGetters[peer].transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				mu.Lock()
+				headers[peer] = req.Header.Get(noForwardHeader)
+				mu.Unlock()
+				return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Body: io.NopCloser(strings.NewReader("v")), Request: req}, nil
+			})
+		}
+
+		local := newLocalGroup(fmt.Sprintf("failover-open-%v", hedge), GetterFunc(func(key string) ([]byte, error) {
+			return nil, errors.New("should not load locally")
+		}))
+		local.RegisterPeers(pool)
+		if v, err := local.Get(key); err != nil || v.String() != "v" {
+			t.Fatalf("hedge %v: failed to get %s: %q %v", hedge, key, v.String(), err)
+		}
+		mu.Lock()
+		if _, ok := headers["http://a"]; ok {
+			t.Fatalf("hedge %v: request sent to the open owner", hedge)
+		}
+		if headers["http://b"] == "" {
+			t.Fatalf("hedge %v: first fallback request should carry %s, got %v", hedge, noForwardHeader, headers)
+		}
+		mu.Unlock()
+	}
+}
+
+// TestFailoverRemoteLoadError 负责节点的 Getter 失败时直接返回错误，
+// 不转移到接替节点，也不回退到本地加载
+func TestFailoverRemoteLoadError(t *testing.T) {
+	var ownerLoads int32
+	NewGroup("failover-load", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) {
+			atomic.AddInt32(&ownerLoads, 1)
+			return nil, errors.New("db is down")
+		}))
+	owner := httptest.NewServer(NewHTTPPool(""))
+	defer owner.Close()
+	var successorReqs int32
+	successor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&successorReqs, 1)
+		w.Write([]byte("stale"))
+	}))
+	defer successor.Close()
+
+	pool := NewHTTPPool("http://self")
+	pool.Set("http://self", owner.URL, successor.URL)
+	var key string
+	for i := 0; key == ""; i++ {
+		k := strconv.Itoa(i)
+		if owners := pool.peers.(consistenthash.ReplicaPicker).GetN(k, 2); owners[0] == owner.URL && owners[1] == successor.URL {
+			key = k
+		}
+	}
+
+	var localLoads int32
+	local := newLocalGroup("failover-load", GetterFunc(func(key string) ([]byte, error) {
+		atomic.AddInt32(&localLoads, 1)
+		return []byte("local"), nil
+	}))
+	local.RegisterPeers(pool)
+	_, err := local.Get(key)
+	if !errors.Is(err, ErrRemoteLoad) || err.Error() != "db is down" {
+		t.Fatalf("expect the owner's load error, got %v", err)
+	}
+	if ownerLoads != 1 || successorReqs != 0 || localLoads != 0 {
+		t.Fatalf("expect one owner load only, got owner %d, successor %d, local %d", ownerLoads, successorReqs, localLoads)
+	}
+	if local.Stats.PeerErrors.Get() != 1 {
+		t.Fatalf("expect one peer error, got %d", local.Stats.PeerErrors.Get())
+	}
+
+	// 批量接口同样不回退
+	if _, errs := local.GetMany([]string{key}); !errors.Is(errs[key], ErrRemoteLoad) || successorReqs != 0 || localLoads != 0 {
+		t.Fatalf("expect the owner's load error from GetMany, got %v", errs[key])
+	}
+}
+
+// fixedPicker 总是选中同一个远程节点
+type fixedPicker struct {
+	peer PeerGetter
+}
+
+func (p fixedPicker) PickPeer(key string) (PeerGetter, bool) {
+	if p.peer == nil {
+		panic("unexpected PickPeer for " + key)
+	}
+	return p.peer, true
+}
+
+func TestServeStats(t *testing.T) {
+	gee := NewGroup("stats", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) {
+			if v, ok := db[key]; ok {
+				return []byte(v), nil
+			}
+			return nil, errors.New(key + " not exist")
+		}))
+	gee.Get("Tom")
+	gee.Get("Tom")
+	gee.Get("unknown")
+
+	srv := httptest.NewServer(NewHTTPPool("self"))
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + defaultBasePath + statsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	var all map[string]struct {
+		Stats     map[string]int64 `json:"stats"`
+		MainCache CacheStats       `json:"main_cache"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&all); err != nil {
+		t.Fatal(err)
+	}
+	got := all["stats"]
+	want := map[string]int64{"gets": 3, "cache_hits": 1, "local_loads": 1, "local_load_errs": 1}
+	for k, v := range want {
+		if got.Stats[k] != v {
+			t.Errorf("stats[%s] = %d, want %d", k, got.Stats[k], v)
+		}
+	}
+	if got.MainCache.Items != 1 || got.MainCache.Bytes != int64(len("Tom")+len("630")) {
+		t.Errorf("unexpected main cache stats: %+v", got.MainCache)
+	}
+}
+
+func TestHTTPPoolMembership(t *testing.T) {
+	pool := NewHTTPPool("http://self")
+	if _, ok := pool.PickPeer("Tom"); ok {
+		t.Fatal("pool without peers should not pick a peer")
+	}
+
+	pool.AddPeers("http://self", "http://a", "http://b")
+	remote := 0
+	for i := 0; i < 100; i++ {
+		if _, ok := pool.PickPeer(strconv.Itoa(i)); ok {
+			remote++
+		}
+	}
+	if remote == 0 {
+		t.Fatal("expect some keys to be owned by remote peers")
+	}
+
+	pool.RemovePeers("http://a", "http://b")
+	if len(pool.httpGetters) != 1 {
+		t.Fatalf("expect 1 getter left, got %d", len(pool.httpGetters))
+	}
+	for i := 0; i < 100; i++ {
+		if peer, ok := pool.PickPeer(strconv.Itoa(i)); ok {
+			t.Fatalf("key %d routed to removed peer %v", i, peer)
+		}
+	}
+}
+
+func TestHTTPPoolWeightedPeers(t *testing.T) {
+	pool := NewHTTPPool("http://self")
+	pool.AddWeightedPeers(map[string]int{"http://self": 1, "http://big": 3})
+	if len(pool.httpGetters) != 2 {
+		t.Fatalf("expect 2 getters, got %d", len(pool.httpGetters))
+	}
+	if members := pool.peers.Members(); len(members) != 2 {
+		t.Fatalf("expect 2 members, got %v", members)
+	}
+	if _, ok := pool.PickPeer("Tom"); !ok {
+		if _, ok := pool.PickPeer("Jack"); !ok {
+			t.Fatal("expect the heavier peer to own some keys")
+		}
+	}
+}
+
+// TestHTTPPoolLoadBound 负责该 key 的节点请求过多时，PickPeer 选择下一个节点
+func TestHTTPPoolLoadBound(t *testing.T) {
+	pool := NewHTTPPool("http://self")
+	pool.Set("http://a", "http://b", "http://c")
+	pool.SetLoadBound(0.25)
+
+	owner := pool.peers.Get("Tom")
+	peer, ok := pool.PickPeer("Tom")
+	if !ok || peer != pool.httpGetters[owner] {
+		t.Fatalf("expect owner %s without load", owner)
+	}
+
+	atomic.StoreInt64(&pool.httpGetters[owner].inflight, 10)
+	peer, ok = pool.PickPeer("Tom")
+	if !ok || peer == pool.httpGetters[owner] {
+		t.Fatalf("overloaded owner %s should be skipped", owner)
+	}
+
+	pool.SetLoadBound(0)
+	if peer, _ := pool.PickPeer("Tom"); peer != pool.httpGetters[owner] {
+		t.Fatalf("expect owner %s when load bound is disabled", owner)
+	}
+}
+
+// TestHTTPPoolLoadBoundDiverted 绕开负责节点的请求带上 NoForward；本节点自己的负载也参与选点
+func TestHTTPPoolLoadBoundDiverted(t *testing.T) {
+	var noForward atomic.Bool
+	pool := NewHTTPPool("http://self")
+	pool.transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		noForward.Store(req.Header.Get(noForwardHeader) != "")
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("v")), Request: req}, nil
+	})
+	pool.Set("http://self", "http://a", "http://b", "http://c")
+	pool.SetLoadBound(0.25)
+
+	// 找一个负责节点是远程节点、环上下一个节点是本节点的 key
+	rp := pool.peers.(consistenthash.ReplicaPicker)
+	var key string
+	for i := 0; key == ""; i++ {
+		k := strconv.Itoa(i)
+		if nodes := rp.GetN(k, 2); nodes[0] != pool.self && nodes[1] == pool.self {
+			key = k
+		}
+	}
+	owner := pool.peers.Get(key)
+	atomic.StoreInt64(&pool.httpGetters[owner].inflight, 50)
+	atomic.StoreInt64(&pool.inflight, 100)
+
+	peer, ok := pool.PickPeer(key)
+	if !ok {
+		t.Fatal("busy self should not be chosen over idle peers")
+	}
+	if _, err := peer.Get("scores", key); err != nil || !noForward.Load() {
+		t.Fatalf("diverted request should carry %s, err %v", noForwardHeader, err)
+	}
+}
+
+// TestHTTPPoolPickerFailover 各放置策略都能给出接替节点，故障转移、熔断绕行和对冲都依赖它
+func TestHTTPPoolPickerFailover(t *testing.T) {
+	for _, newPicker := range []func() consistenthash.Picker{
+		func() consistenthash.Picker { return consistenthash.NewRendezvous() },
+		func() consistenthash.Picker { return consistenthash.NewJump() },
+		func() consistenthash.Picker { return consistenthash.NewMaglev(0) },
+	} {
+		pool := NewHTTPPool("http://self")
+		pool.SetPicker(newPicker)
+		pool.Set("http://a", "http://b", "http://c")
+		if peers := pool.PickPeers("Tom"); len(peers) != defaultFailoverPeers {
+			t.Fatalf("%T: expect %d failover peers, got %d", pool.peers, defaultFailoverPeers, len(peers))
+		}
+	}
+}
+
+func TestHTTPPoolSetPicker(t *testing.T) {
+	pool := NewHTTPPool("http://self")
+	pool.Set("http://self", "http://a", "http://b")
+
+	for _, newPicker := range []func() consistenthash.Picker{
+		func() consistenthash.Picker { return consistenthash.NewRendezvous() },
+		func() consistenthash.Picker { return consistenthash.NewJump() },
+		func() consistenthash.Picker { return consistenthash.NewMaglev(0) },
+	} {
+		pool.SetPicker(newPicker)
+		if members := pool.peers.Members(); len(members) != 3 {
+			t.Fatalf("%T: expect 3 members after switching, got %v", pool.peers, members)
+		}
+		for i := 0; i < 100; i++ {
+			key := strconv.Itoa(i)
+			peer, ok := pool.PickPeer(key)
+			if owner := pool.peers.Get(key); ok != (owner != "http://self") || (ok && peer != pool.httpGetters[owner]) {
+				t.Fatalf("%T: key %s picked %v, owner %s", pool.peers, key, peer, owner)
+			}
+		}
+	}
+}
+
+// TestHTTPPoolSetPickerWeights 换策略后节点沿用 AddWeightedPeers 设置的权重
+func TestHTTPPoolSetPickerWeights(t *testing.T) {
+	pool := NewHTTPPool("http://self")
+	pool.AddWeightedPeers(map[string]int{"http://small": 1, "http://big": 3})
+	pool.AddPeers("http://plain")
+
+	for _, newPicker := range []func() consistenthash.Picker{
+		func() consistenthash.Picker { return consistenthash.NewRendezvous() },
+		nil,
+	} {
+		pool.SetPicker(newPicker)
+		counts := map[string]int{}
+		for i := 0; i < 10000; i++ {
+			counts[pool.peers.Get(strconv.Itoa(i))]++
+		}
+		// 权重 1:3:1，big 应分到约 60% 的 key
+		if share := float64(counts["http://big"]) / 10000; share < 0.5 || share > 0.7 {
+			t.Fatalf("%T: expect big to own ~60%% of keys, got %v", pool.peers, counts)
+		}
+		if counts["http://small"] == 0 || counts["http://plain"] == 0 {
+			t.Fatalf("%T: expect every peer to own some keys, got %v", pool.peers, counts)
+		}
+	}
+}
+
+// TestHTTPPoolConcurrentMembership 成员变更与 PickPeer 并发执行（配合 -race）
+func TestHTTPPoolConcurrentMembership(t *testing.T) {
+	pool := NewHTTPPool("http://self")
+	pool.Set("http://self", "http://a")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			pool.AddPeers("http://b")
+			pool.RemovePeers("http://b")
+		}
+	}()
+	for i := 0; i < 1000; i++ {
+		if peer, ok := pool.PickPeer(strconv.Itoa(i)); ok && peer == nil {
+			t.Fatal("picked nil peer")
+		}
+	}
+	<-done
+}
+
+func TestNewHTTPPoolOpts(t *testing.T) {
+	var hashed int32
+	var sent int32
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&sent, 1)
+		if !strings.HasPrefix(req.URL.Path, "/cache/") {
+			t.Errorf("unexpected path %s", req.URL.Path)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK",
+			Body: io.NopCloser(strings.NewReader("v")), Request: req}, nil
+	})
+	pool := NewHTTPPoolOpts("http://self", &HTTPPoolOptions{
+		BasePath:  "/cache/",
+		Replicas:  3,
+		HashFn:    func(data []byte) uint32 { atomic.AddInt32(&hashed, 1); return crc32.ChecksumIEEE(data) },
+		Transport: transport,
+	})
+	pool.Set("http://a", "http://b")
+	if n := atomic.LoadInt32(&hashed); n != 6 {
+		t.Fatalf("expect 2 peers * 3 replicas hashed, got %d", n)
+	}
+
+	// 所有 httpGetter 共享同一个 Transport
+	for _, peer := range []string{"Tom", "Jack", "Sam"} {
+		getter, ok := pool.PickPeer(peer)
+		if !ok {
+			t.Fatal("expect a remote peer")
+		}
+		if _, err := getter.Get("scores", peer); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if sent != 3 {
+		t.Fatalf("expect 3 requests through the shared transport, got %d", sent)
+	}
+}
+
+func TestNewHTTPPoolDefaults(t *testing.T) {
+	pool := NewHTTPPool("http://self")
+	if pool.basePath != defaultBasePath || pool.replicas != defaultReplicas {
+		t.Fatalf("unexpected defaults: %q %d", pool.basePath, pool.replicas)
+	}
+	tr, ok := pool.transport.(*http.Transport)
+	if !ok || tr.MaxIdleConnsPerHost != defaultMaxIdleConnsPerPeer {
+		t.Fatalf("expect tuned transport, got %#v", pool.transport)
+	}
+	pool.Set("http://a", "http://b")
+	if pool.httpGetters["http://a"].transport != pool.httpGetters["http://b"].transport {
+		t.Fatal("expect transport shared across getters")
+	}
+}
+
+// TestHTTPPoolTimeout 单次请求超时：远程节点收到的超时不超过 Timeout，超时后返回错误
+func TestHTTPPoolTimeout(t *testing.T) {
+	pool := newTimeoutPool(t)
+	getter, _ := pool.PickPeer("Tom")
+
+	start := time.Now()
+	if _, err := getter.Get("scores", "Tom"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expect DeadlineExceeded, got %v", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("request took %v despite timeout", d)
+	}
+
+	// 批量请求同样受 Timeout 限制
+	start = time.Now()
+	_, errs := getter.(BatchPeerGetter).GetMany(context.Background(), "scores", []string{"Tom", "Jack"})
+	if !errors.Is(errs["Tom"], context.DeadlineExceeded) || !errors.Is(errs["Jack"], context.DeadlineExceeded) {
+		t.Fatalf("expect DeadlineExceeded for every key, got %v", errs)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("batch request took %v despite timeout", d)
+	}
+
+	// 写入同样受 Timeout 限制
+	start = time.Now()
+	if err := getter.(PeerSetter).Set(context.Background(), "scores", "Tom", []byte("630")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expect DeadlineExceeded from Set, got %v", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("Set took %v despite timeout", d)
+	}
+
+	// 删除同样受 Timeout 限制
+	start = time.Now()
+	if err := getter.(PeerRemover).Remove(context.Background(), "scores", "Tom"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expect DeadlineExceeded from Remove, got %v", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("Remove took %v despite timeout", d)
+	}
+}
+
+// newTimeoutPool 返回一个 Timeout 为 20ms、远程节点永不响应的 HTTPPool，节点为 http://a
+func newTimeoutPool(t *testing.T) *HTTPPool {
+	pool := NewHTTPPoolOpts("http://self", &HTTPPoolOptions{
+		Timeout: 20 * time.Millisecond,
+		Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			if ms, _ := strconv.Atoi(req.Header.Get(timeoutHeader)); ms <= 0 || ms > 20 {
+				t.Errorf("unexpected timeout header %q", req.Header.Get(timeoutHeader))
+			}
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		}),
+	})
+	pool.Set("http://a")
+	return pool
+}
+
+// TestSet 在进程内的两个节点上测试写入：写入被转发给负责节点并进入它的 mainCache，
+// 本节点 hotCache 中的旧值被替换
+func TestSet(t *testing.T) {
+	defer func(p int) { hotCachePercent = p }(hotCachePercent)
+	hotCachePercent = 100
+
+	var remoteLoads int32
+	remote := NewGroup("set-cluster", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		atomic.AddInt32(&remoteLoads, 1)
+		return []byte("old"), nil
+	}))
+	srv := httptest.NewServer(NewHTTPPool(""))
+	defer srv.Close()
+
+	pool := NewHTTPPool("http://self")
+	pool.Set(srv.URL)
+	local := newLocalGroup("set-cluster", GetterFunc(func(key string) ([]byte, error) {
+		return nil, errors.New("should not load locally")
+	}))
+	local.RegisterPeers(pool)
+
+	if v, err := local.Get("Tom"); err != nil || v.String() != "old" {
+		t.Fatalf("failed to get Tom: %v %v", v, err)
+	}
+	if err := local.Set("Tom", []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := remote.Get("Tom"); v.String() != "new" || remoteLoads != 1 {
+		t.Fatalf("owner should serve the new value from mainCache, got %q after %d loads", v.String(), remoteLoads)
+	}
+	if v, _ := local.hotCache.get("Tom"); v.String() != "new" {
+		t.Fatalf("stale hot cache value %q", v.String())
+	}
+	if err := local.Set("Jack", []byte("589")); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := local.Get("Jack"); err != nil || v.String() != "589" || remoteLoads != 1 {
+		t.Fatalf("expect Jack from owner's mainCache, got %q %v after %d loads", v.String(), err, remoteLoads)
+	}
+	if remote.Stats.Sets.Get() != 2 || local.Stats.Sets.Get() != 0 {
+		t.Fatalf("unexpected set stats: remote %d, local %d", remote.Stats.Sets.Get(), local.Stats.Sets.Get())
+	}
+	if remote.Stats.PeerSets.Get() != 0 || local.Stats.PeerSets.Get() != 2 {
+		t.Fatalf("unexpected peer set stats: remote %d, local %d", remote.Stats.PeerSets.Get(), local.Stats.PeerSets.Get())
+	}
+	// 替换 hotCache 中的旧值不算一次读取
+	gets := local.hotCache.stats().Gets
+	if err := local.Set("Tom", []byte("newer")); err != nil {
+		t.Fatal(err)
+	}
+	if n := local.hotCache.stats().Gets; n != gets {
+		t.Fatalf("Set should not count hot cache gets, got %d -> %d", gets, n)
+	}
+}
+
+func TestSetLocal(t *testing.T) {
+	gee := newLocalGroup("set-local", GetterFunc(func(key string) ([]byte, error) {
+		return nil, errors.New("should not load")
+	}))
+	value := []byte("630")
+	if err := gee.Set("Tom", value); err != nil {
+		t.Fatal(err)
+	}
+	value[0] = 'x' // Set 应拷贝 value
+	if v, err := gee.Get("Tom"); err != nil || v.String() != "630" {
+		t.Fatalf("expect value from mainCache, got %q %v", v.String(), err)
+	}
+	if err := gee.Set("", value); err == nil {
+		t.Fatal("expect error for empty key")
+	}
+
+	// 远程节点不支持写入时返回错误
+	other := newLocalGroup("set-unsupported", GetterFunc(dbGet))
+	other.RegisterPeers(fixedPicker{peerGetterFunc(nil)})
+	if err := other.Set("Tom", value); err == nil {
+		t.Fatal("expect error when the owner does not implement PeerSetter")
+	}
+}
+
+// TestSetOwnerUnavailable 负责节点熔断时 Set 返回错误，既不写到接替节点也不写到本节点
+func TestSetOwnerUnavailable(t *testing.T) {
+	pool := NewHTTPPool("http://self")
+	pool.SetCircuitBreaker(&CircuitBreakerOptions{Window: time.Minute, MinRequests: 1, FailureRate: 1, Cooldown: time.Minute})
+	pool.Set("http://self", "http://a")
+	var calls int32
+	pool.httpGetters["http://a"].transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, errors.New("unexpected request")
+	})
+	var key string
+	for i := 0; key == ""; i++ {
+		if k := strconv.Itoa(i); pool.peers.Get(k) == "http://a" {
+			key = k
+		}
+	}
+	b := pool.httpGetters["http://a"].breaker
+	gen, _ := b.allow()
+	b.record(gen, false)
+
+	local := newLocalGroup("set-unavailable", GetterFunc(dbGet))
+	local.RegisterPeers(pool)
+	if err := local.Set(key, []byte("v")); !errors.Is(err, errCircuitOpen) {
+		t.Fatalf("expect errCircuitOpen, got %v", err)
+	}
+	if _, ok := local.mainCache.get(key); ok || local.Stats.Sets.Get() != 0 || calls != 0 {
+		t.Fatalf("value should not be written anywhere, sent %d requests", calls)
+	}
+}
+
+// peerGetterFunc 是只实现了 PeerGetter 的远程节点
+type peerGetterFunc func(group, key string) ([]byte, error)
+
+func (f peerGetterFunc) Get(group, key string) ([]byte, error) {
+	return f(group, key)
+}
+
+// TestRemove 删除广播到所有节点：本节点和负责节点都删除成功，宕机的节点被报告出来
+func TestRemove(t *testing.T) {
+	var remoteLoads int32
+	remote := NewGroup("remove-cluster", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		atomic.AddInt32(&remoteLoads, 1)
+		return []byte("v" + key), nil
+	}))
+	srv := httptest.NewServer(NewHTTPPool(""))
+	defer srv.Close()
+	dead := httptest.NewServer(nil)
+	dead.Close()
+
+	pool := NewHTTPPool("http://self")
+	pool.Set(srv.URL, dead.URL)
+	local := newLocalGroup("remove-cluster", GetterFunc(dbGet))
+	local.RegisterPeers(pool)
+
+	remote.Get("Tom")
+	local.hotCache.add("Tom", ByteView{b: []byte("vTom")})
+	local.mainCache.add("Tom", ByteView{b: []byte("vTom")})
+
+	err := local.Remove("Tom")
+	var re *RemoveError
+	if !errors.As(err, &re) || len(re.Failed) != 1 || re.Failed[dead.URL] == nil {
+		t.Fatalf("expect only the dead peer to fail, got %v", err)
+	}
+	if _, ok := local.lookupCache("Tom"); ok {
+		t.Fatal("Tom still cached locally")
+	}
+	if _, ok := remote.lookupCache("Tom"); ok {
+		t.Fatal("Tom still cached on the owner")
+	}
+	if remote.Stats.Removes.Get() != 1 {
+		t.Fatalf("expect one remove on the owner, got %d", remote.Stats.Removes.Get())
+	}
+	remote.Get("Tom")
+	if remoteLoads != 2 {
+		t.Fatalf("expect Tom reloaded on the owner, loaded %d times", remoteLoads)
+	}
+}
+
+// TestRemoveCircuitBreaker 删除失败计入熔断器，熔断后广播删除不再向该节点发请求
+func TestRemoveCircuitBreaker(t *testing.T) {
+	pool := NewHTTPPool("http://self")
+	pool.SetCircuitBreaker(&CircuitBreakerOptions{Window: time.Minute, MinRequests: 1, FailureRate: 1, Cooldown: time.Minute})
+	pool.Set("http://self", "http://a")
+	var calls int32
+	pool.httpGetters["http://a"].transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, errors.New("connection refused")
+	})
+	local := newLocalGroup("remove-breaker", GetterFunc(dbGet))
+	local.RegisterPeers(pool)
+
+	var re *RemoveError
+	if err := local.Remove("Tom"); !errors.As(err, &re) || errors.Is(re.Failed["http://a"], errCircuitOpen) {
+		t.Fatalf("expect the first remove to reach the peer, got %v", err)
+	}
+	if err := local.Remove("Tom"); !errors.As(err, &re) || !errors.Is(re.Failed["http://a"], errCircuitOpen) {
+		t.Fatalf("expect errCircuitOpen once the breaker opens, got %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("expect 1 request to the failing peer, got %d", calls)
+	}
+}
+
+func TestRemoveLocal(t *testing.T) {
+	var loads int32
+	gee := newLocalGroup("remove-local", GetterFunc(func(key string) ([]byte, error) {
+		atomic.AddInt32(&loads, 1)
+		return dbGet(key)
+	}))
+	gee.Get("Tom")
+	if err := gee.Remove("Tom"); err != nil {
+		t.Fatal(err)
+	}
+	gee.Get("Tom")
+	if loads != 2 {
+		t.Fatalf("expect reload after Remove, loaded %d times", loads)
+	}
+	if st := gee.CacheStats(MainCache); st.Evictions != 0 || st.Items != 1 {
+		t.Fatalf("Remove should not count as an eviction: %+v", st)
+	}
+	if err := gee.Remove(""); err == nil {
+		t.Fatal("expect error for empty key")
+	}
+}
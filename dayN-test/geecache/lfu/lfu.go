@@ -0,0 +1,224 @@
+// Package lfu 实现了 O(1) 的 LFU（最不经常使用）淘汰策略，满足 lru.Store 接口。
+// 记录按访问次数分组挂在频率链表上：淘汰时取访问次数最少的一组里最久未访问的记录，
+// 访问时把记录移到"次数+1"的那一组，新增、访问、淘汰都是 O(1)。
+package lfu
+
+import (
+	"container/list"
+	"geecache/lru"
+	"time"
+)
+
+type Cache struct {
+	maxBytes  int64
+	nbytes    int64
+	freqs     *list.List // 元素是 *freqNode，按 freq 升序排列
+	cache     map[string]*entry
+	OnEvicted func(key string, value lru.Value)
+	// OnEvictedWithReason 与 OnEvicted 相同，但额外告知淘汰原因（容量不足或过期）
+	OnEvictedWithReason func(key string, value lru.Value, reason lru.EvictReason)
+	// Now 返回当前时间，用于判断过期；为 nil 时使用 time.Now
+	Now func() time.Time
+}
+
+// freqNode 是访问次数相同的一组记录，items 头部是最近访问的
+type freqNode struct {
+	freq  int
+	items *list.List // 元素是 *entry
+}
+
+type entry struct {
+	key    string
+	value  lru.Value
+	expire time.Time     // 零值表示永不过期
+	node   *list.Element // 所在的 freqNode
+	ele    *list.Element // 在 freqNode.items 中的位置
+}
+
+func New(maxBytes int64, onEvicted func(string, lru.Value)) *Cache {
+	return &Cache{
+		maxBytes:  maxBytes,
+		freqs:     list.New(),
+		cache:     make(map[string]*entry),
+		OnEvicted: onEvicted,
+	}
+}
+
+// NewStore 是 LFU 策略的 lru.Policy
+func NewStore(maxBytes int64, onEvicted func(key string, value lru.Value)) lru.Store {
+	return New(maxBytes, onEvicted)
+}
+
+var (
+	_ lru.Store          = (*Cache)(nil)
+	_ lru.Victimer       = (*Cache)(nil)
+	_ lru.Clocked        = (*Cache)(nil)
+	_ lru.ReasonNotifier = (*Cache)(nil)
+)
+
+// Add 添加一条永不过期的记录
+func (c *Cache) Add(key string, value lru.Value) {
+	c.AddWithTTL(key, value, 0)
+}
+
+// AddWithTTL 添加一条记录，ttl 之后过期；ttl <= 0 表示永不过期。
+// 更新已存在的 key 也算一次访问
+func (c *Cache) AddWithTTL(key string, value lru.Value, ttl time.Duration) {
+	var expire time.Time
+	if ttl > 0 {
+		expire = c.now().Add(ttl)
+	}
+	if e, ok := c.cache[key]; ok {
+		c.nbytes += int64(value.Len()) - int64(e.value.Len())
+		e.value = value
+		e.expire = expire
+		c.increment(e)
+	} else {
+		// 先腾出空间再插入，否则访问次数为 1 的新记录往往会把自己淘汰掉
+		size := int64(len(key)) + int64(value.Len())
+		for c.maxBytes != 0 && c.maxBytes < c.nbytes+size && len(c.cache) > 0 {
+			c.RemoveLeastFrequent()
+		}
+		front := c.freqs.Front()
+		if front == nil || front.Value.(*freqNode).freq != 1 {
+			front = c.freqs.PushFront(&freqNode{freq: 1, items: list.New()})
+		}
+		e := &entry{key: key, value: value, expire: expire, node: front}
+		e.ele = front.Value.(*freqNode).items.PushFront(e)
+		c.cache[key] = e
+		c.nbytes += size
+	}
+	for c.maxBytes != 0 && c.maxBytes < c.nbytes {
+		c.RemoveLeastFrequent()
+	}
+}
+
+// Get 查找 key 并把访问次数加一；已过期的记录会在这里被惰性删除并视为未命中
+func (c *Cache) Get(key string) (value lru.Value, ok bool) {
+	if e, ok := c.cache[key]; ok {
+		if e.expired(c.now()) {
+			c.removeEntry(e, lru.EvictExpired)
+			return nil, false
+		}
+		c.increment(e)
+		return e.value, true
+	}
+	return
+}
+
+// Contains 判断 key 是否在缓存中，不增加访问次数
+func (c *Cache) Contains(key string) bool {
+	_, ok := c.cache[key]
+	return ok
+}
+
+// RemoveLeastFrequent 淘汰访问次数最少的记录，次数相同时淘汰最久未访问的
+func (c *Cache) RemoveLeastFrequent() {
+	front := c.freqs.Front()
+	if front == nil {
+		return
+	}
+	if ele := front.Value.(*freqNode).items.Back(); ele != nil {
+		c.removeEntry(ele.Value.(*entry), lru.EvictCapacity)
+	}
+}
+
+// Remove 主动删除 key，返回它是否存在；不调用 OnEvicted
+func (c *Cache) Remove(key string) bool {
+	e, ok := c.cache[key]
+	if !ok {
+		return false
+	}
+	c.unlink(e)
+	return true
+}
+
+// Victim 返回容量不足时下一个会被淘汰的 key
+func (c *Cache) Victim() (key string, ok bool) {
+	if front := c.freqs.Front(); front != nil {
+		if ele := front.Value.(*freqNode).items.Back(); ele != nil {
+			return ele.Value.(*entry).key, true
+		}
+	}
+	return "", false
+}
+
+// RemoveExpired 删除所有已过期的记录，返回回收的字节数
+func (c *Cache) RemoveExpired() int64 {
+	now := c.now()
+	var freed int64
+	for _, e := range c.cache {
+		if e.expired(now) {
+			freed += int64(len(e.key)) + int64(e.value.Len())
+			c.removeEntry(e, lru.EvictExpired)
+		}
+	}
+	return freed
+}
+
+func (c *Cache) Len() int {
+	return len(c.cache)
+}
+
+// Bytes 返回当前已使用的字节数（key + value）
+func (c *Cache) Bytes() int64 {
+	return c.nbytes
+}
+
+// increment 把 e 移到访问次数 +1 的 freqNode，必要时新建该节点、删除空节点
+func (c *Cache) increment(e *entry) {
+	cur := e.node
+	fn := cur.Value.(*freqNode)
+	next := cur.Next()
+	if next == nil || next.Value.(*freqNode).freq != fn.freq+1 {
+		next = c.freqs.InsertAfter(&freqNode{freq: fn.freq + 1, items: list.New()}, cur)
+	}
+	fn.items.Remove(e.ele)
+	e.ele = next.Value.(*freqNode).items.PushFront(e)
+	e.node = next
+	if fn.items.Len() == 0 {
+		c.freqs.Remove(cur)
+	}
+}
+
+func (c *Cache) removeEntry(e *entry, reason lru.EvictReason) {
+	c.unlink(e)
+	if c.OnEvicted != nil {
+		c.OnEvicted(e.key, e.value)
+	}
+	if c.OnEvictedWithReason != nil {
+		c.OnEvictedWithReason(e.key, e.value, reason)
+	}
+}
+
+// unlink 把记录从频次链表和字典中删除并更新字节数，不调用回调
+func (c *Cache) unlink(e *entry) {
+	fn := e.node.Value.(*freqNode)
+	fn.items.Remove(e.ele)
+	if fn.items.Len() == 0 {
+		c.freqs.Remove(e.node)
+	}
+	delete(c.cache, e.key)
+	c.nbytes -= int64(len(e.key)) + int64(e.value.Len())
+}
+
+// SetOnEvictedWithReason 设置 OnEvictedWithReason（实现了 lru.ReasonNotifier 接口）
+func (c *Cache) SetOnEvictedWithReason(fn func(key string, value lru.Value, reason lru.EvictReason)) {
+	c.OnEvictedWithReason = fn
+}
+
+// SetNow 替换判断过期用的时钟，nil 表示使用 time.Now（实现了 lru.Clocked 接口）
+func (c *Cache) SetNow(now func() time.Time) {
+	c.Now = now
+}
+
+func (c *Cache) now() time.Time {
+	if c.Now != nil {
+		return c.Now()
+	}
+	return time.Now()
+}
+
+func (e *entry) expired(now time.Time) bool {
+	return !e.expire.IsZero() && !now.Before(e.expire)
+}
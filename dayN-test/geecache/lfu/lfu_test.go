@@ -0,0 +1,41 @@
+package lfu
+
+import (
+	"geecache/lru"
+	"geecache/lru/storetest"
+	"reflect"
+	"testing"
+)
+
+type String string
+
+func (d String) Len() int {
+	return len(d)
+}
+
+func TestStore(t *testing.T) {
+	storetest.TestStore(t, NewStore)
+}
+
+// TestRemoveLeastFrequent 访问次数最少的先被淘汰，次数相同时淘汰最久未访问的
+func TestRemoveLeastFrequent(t *testing.T) {
+	var keys []string
+	lfu := New(int64(len("k1v1")*3), func(key string, value lru.Value) {
+		keys = append(keys, key)
+	})
+	lfu.Add("k1", String("v1"))
+	lfu.Add("k2", String("v2"))
+	lfu.Add("k3", String("v3"))
+	lfu.Get("k1")
+	lfu.Get("k1")
+	lfu.Get("k3")
+
+	lfu.Add("k4", String("v4")) // k2 访问次数最少
+	lfu.Get("k4")
+	lfu.Add("k5", String("v5")) // k3、k4 次数都是 2，k3 更久未访问
+
+	expect := []string{"k2", "k3"}
+	if !reflect.DeepEqual(expect, keys) {
+		t.Fatalf("expect evicted %v, got %v", expect, keys)
+	}
+}
@@ -1,7 +1,10 @@
 // 相比day4无变化
 package lru
 
-import "container/list"
+import (
+	"container/list"
+	"time"
+)
 
 type Cache struct {
 	maxBytes  int64
@@ -9,11 +12,34 @@ type Cache struct {
 	ll        *list.List
 	cache     map[string]*list.Element
 	OnEvicted func(key string, value Value)
+	// OnEvictedWithReason 与 OnEvicted 相同，但额外告知淘汰原因（容量不足或过期）
+	OnEvictedWithReason func(key string, value Value, reason EvictReason)
+	// Now 返回当前时间，用于判断过期；为 nil 时使用 time.Now，测试中可替换为假时钟
+	Now func() time.Time
 }
 
 type entry struct {
-	key   string
-	value Value
+	key    string
+	value  Value
+	expire time.Time // 零值表示永不过期
+}
+
+// EvictReason 表示记录被移出缓存的原因
+type EvictReason int
+
+const (
+	EvictCapacity EvictReason = iota // 超出 maxBytes，按 LRU 淘汰
+	EvictExpired                     // TTL 到期
+)
+
+func (r EvictReason) String() string {
+	switch r {
+	case EvictCapacity:
+		return "capacity"
+	case EvictExpired:
+		return "expired"
+	}
+	return "unknown"
 }
 
 type Value interface {
@@ -29,14 +55,26 @@ func New(maxBytes int64, onEvicted func(string, Value)) *Cache {
 	}
 }
 
+// Add 添加一条永不过期的记录
 func (c *Cache) Add(key string, value Value) {
+	c.AddWithTTL(key, value, 0)
+}
+
+// AddWithTTL 添加一条记录，ttl 之后过期；ttl <= 0 表示永不过期。
+// 更新已存在的 key 时，过期时间也会按新的 ttl 重置
+func (c *Cache) AddWithTTL(key string, value Value, ttl time.Duration) {
+	var expire time.Time
+	if ttl > 0 {
+		expire = c.now().Add(ttl)
+	}
 	if ele, ok := c.cache[key]; ok {
 		c.ll.MoveToFront(ele)
 		kv := ele.Value.(*entry)
 		c.nbytes += int64(value.Len()) - int64(kv.value.Len())
 		kv.value = value
+		kv.expire = expire
 	} else {
-		ele := c.ll.PushFront(&entry{key, value})
+		ele := c.ll.PushFront(&entry{key, value, expire})
 		c.cache[key] = ele
 		c.nbytes += int64(len(key)) + int64(value.Len())
 	}
@@ -45,28 +83,113 @@ func (c *Cache) Add(key string, value Value) {
 	}
 }
 
+// Get 查找 key；已过期的记录会在这里被惰性删除并视为未命中
 func (c *Cache) Get(key string) (value Value, ok bool) {
 	if ele, ok := c.cache[key]; ok {
-		c.ll.MoveToFront(ele)
 		kv := ele.Value.(*entry)
+		if kv.expired(c.now()) {
+			c.removeElement(ele, EvictExpired)
+			return nil, false
+		}
+		c.ll.MoveToFront(ele)
 		return kv.value, true
 	}
 	return
 }
 
+// Contains 判断 key 是否在缓存中，不移动它的位置
+func (c *Cache) Contains(key string) bool {
+	_, ok := c.cache[key]
+	return ok
+}
+
 func (c *Cache) RemoveOldest() {
 	ele := c.ll.Back()
 	if ele != nil {
-		c.ll.Remove(ele)
-		kv := ele.Value.(*entry)
-		delete(c.cache, kv.key)
-		c.nbytes -= int64(len(kv.key)) + int64(kv.value.Len())
-		if c.OnEvicted != nil {
-			c.OnEvicted(kv.key, kv.value)
+		c.removeElement(ele, EvictCapacity)
+	}
+}
+
+// Remove 主动删除 key，返回它是否存在。
+// 这是调用方自己的操作而不是淘汰，因此不调用 OnEvicted
+func (c *Cache) Remove(key string) bool {
+	ele, ok := c.cache[key]
+	if !ok {
+		return false
+	}
+	c.unlink(ele)
+	return true
+}
+
+// Victim 返回容量不足时下一个会被淘汰的 key，即最久未访问的记录
+func (c *Cache) Victim() (key string, ok bool) {
+	if ele := c.ll.Back(); ele != nil {
+		return ele.Value.(*entry).key, true
+	}
+	return "", false
+}
+
+// RemoveExpired 删除所有已过期的记录，返回回收的字节数。
+// Get 只删除被访问到的过期记录，过期后再没被访问过的记录由调用方（如 geecache 的后台清理）调用它回收
+func (c *Cache) RemoveExpired() int64 {
+	now := c.now()
+	var freed int64
+	for ele := c.ll.Back(); ele != nil; {
+		prev := ele.Prev()
+		if kv := ele.Value.(*entry); kv.expired(now) {
+			freed += int64(len(kv.key)) + int64(kv.value.Len())
+			c.removeElement(ele, EvictExpired)
 		}
+		ele = prev
+	}
+	return freed
+}
+
+func (c *Cache) removeElement(ele *list.Element, reason EvictReason) {
+	kv := c.unlink(ele)
+	if c.OnEvicted != nil {
+		c.OnEvicted(kv.key, kv.value)
+	}
+	if c.OnEvictedWithReason != nil {
+		c.OnEvictedWithReason(kv.key, kv.value, reason)
+	}
+}
+
+// unlink 把记录从链表和字典中删除并更新字节数，不调用回调
+func (c *Cache) unlink(ele *list.Element) *entry {
+	c.ll.Remove(ele)
+	kv := ele.Value.(*entry)
+	delete(c.cache, kv.key)
+	c.nbytes -= int64(len(kv.key)) + int64(kv.value.Len())
+	return kv
+}
+
+// SetOnEvictedWithReason 设置 OnEvictedWithReason（实现了 lru.ReasonNotifier 接口）
+func (c *Cache) SetOnEvictedWithReason(fn func(key string, value Value, reason EvictReason)) {
+	c.OnEvictedWithReason = fn
+}
+
+// SetNow 替换判断过期用的时钟，nil 表示使用 time.Now（实现了 lru.Clocked 接口）
+func (c *Cache) SetNow(now func() time.Time) {
+	c.Now = now
+}
+
+func (c *Cache) now() time.Time {
+	if c.Now != nil {
+		return c.Now()
 	}
+	return time.Now()
+}
+
+func (e *entry) expired(now time.Time) bool {
+	return !e.expire.IsZero() && !now.Before(e.expire)
 }
 
 func (c *Cache) Len() int {
 	return c.ll.Len()
 }
+
+// Bytes 返回当前已使用的字节数（key + value）
+func (c *Cache) Bytes() int64 {
+	return c.nbytes
+}
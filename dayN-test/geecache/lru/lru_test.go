@@ -4,6 +4,7 @@ package lru
 import (
 	"reflect"
 	"testing"
+	"time"
 )
 
 type String string
@@ -64,3 +65,68 @@ func TestAdd(t *testing.T) {
 		t.Fatal("expected 6 but got", lru.nbytes)
 	}
 }
+
+// fakeClock 可手动拨动的时钟，用于测试过期逻辑
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func TestAddWithTTL(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	reasons := make(map[string]EvictReason)
+	lru := New(int64(0), nil)
+	lru.Now = clock.Now
+	lru.OnEvictedWithReason = func(key string, value Value, reason EvictReason) {
+		reasons[key] = reason
+	}
+
+	lru.AddWithTTL("key1", String("1234"), time.Second)
+	lru.Add("key2", String("5678"))
+
+	clock.Advance(500 * time.Millisecond)
+	if _, ok := lru.Get("key1"); !ok {
+		t.Fatalf("key1 should not expire before ttl")
+	}
+
+	clock.Advance(500 * time.Millisecond)
+	if _, ok := lru.Get("key1"); ok {
+		t.Fatalf("key1 should expire after ttl")
+	}
+	if _, ok := lru.Get("key2"); !ok {
+		t.Fatalf("key2 without ttl should never expire")
+	}
+	if reasons["key1"] != EvictExpired {
+		t.Fatalf("expect key1 evicted with reason %v, got %v", EvictExpired, reasons["key1"])
+	}
+	if lru.nbytes != int64(len("key2")+len("5678")) {
+		t.Fatal("expected 8 but got", lru.nbytes)
+	}
+}
+
+func TestRemoveExpired(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	var expired []string
+	lru := New(int64(0), nil)
+	lru.Now = clock.Now
+	lru.OnEvictedWithReason = func(key string, value Value, reason EvictReason) {
+		if reason == EvictExpired {
+			expired = append(expired, key)
+		}
+	}
+
+	lru.AddWithTTL("k1", String("v1"), time.Second)
+	lru.AddWithTTL("k2", String("v2"), 2*time.Second)
+	lru.Add("k3", String("v3"))
+
+	clock.Advance(time.Second)
+	if freed := lru.RemoveExpired(); freed != int64(len("k1")+len("v1")) {
+		t.Fatalf("expect 4 bytes freed, got %d", freed)
+	}
+	if !reflect.DeepEqual(expired, []string{"k1"}) || lru.Len() != 2 {
+		t.Fatalf("RemoveExpired should only remove k1, got %v", expired)
+	}
+}
@@ -0,0 +1,49 @@
+package lru
+
+import "time"
+
+// Store 抽象了按字节限制容量的缓存淘汰策略，geecache 的 cache 只依赖这个接口。
+// 实现不要求并发安全，由调用方加锁。
+// 超出容量时淘汰的记录、以及过期的记录都要通过 OnEvicted 回调通知调用方
+type Store interface {
+	Add(key string, value Value)
+	AddWithTTL(key string, value Value, ttl time.Duration)
+	Get(key string) (value Value, ok bool)
+	Contains(key string) bool // key 是否在缓存中；不更新访问顺序或频率，也不检查、不删除过期记录
+	Remove(key string) bool   // 主动删除 key，返回它是否存在；不算淘汰，不调用 OnEvicted
+	RemoveExpired() int64     // 删除所有已过期记录，返回回收的字节数
+	Len() int                 // 记录条数
+	Bytes() int64             // 已使用的字节数（key + value）
+}
+
+// Victimer 由能预知下一个容量淘汰对象的 Store 实现，
+// 准入策略（如 tinylfu）用它把新记录和将被淘汰的记录比较访问频率
+type Victimer interface {
+	Victim() (key string, ok bool)
+}
+
+// Clocked 由可以替换时钟的 Store 实现，geecache 在测试中用它给所有策略注入假时钟
+type Clocked interface {
+	SetNow(now func() time.Time)
+}
+
+// ReasonNotifier 由能报告淘汰原因的 Store 实现：设置的 fn 在每次淘汰时与 OnEvicted 一起调用，
+// geecache 据此只把容量淘汰计入 CacheStats.Evictions，过期不算
+type ReasonNotifier interface {
+	SetOnEvictedWithReason(fn func(key string, value Value, reason EvictReason))
+}
+
+// Policy 按容量和淘汰回调创建一个 Store，用来在 Group 上选择淘汰策略
+type Policy func(maxBytes int64, onEvicted func(key string, value Value)) Store
+
+// NewStore 是 LRU 策略的 Policy，也是 geecache 的默认策略
+func NewStore(maxBytes int64, onEvicted func(key string, value Value)) Store {
+	return New(maxBytes, onEvicted)
+}
+
+var (
+	_ Store          = (*Cache)(nil)
+	_ Victimer       = (*Cache)(nil)
+	_ Clocked        = (*Cache)(nil)
+	_ ReasonNotifier = (*Cache)(nil)
+)
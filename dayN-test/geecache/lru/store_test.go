@@ -0,0 +1,11 @@
+package lru_test
+
+import (
+	"geecache/lru"
+	"geecache/lru/storetest"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	storetest.TestStore(t, lru.NewStore)
+}
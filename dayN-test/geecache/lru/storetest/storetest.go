@@ -0,0 +1,166 @@
+// Package storetest 提供 lru.Store 实现共用的一致性测试，
+// 各淘汰策略只在"淘汰谁"上不同，字节统计、回调和过期行为必须一致
+package storetest
+
+import (
+	"geecache/lru"
+	"testing"
+	"time"
+)
+
+type String string
+
+func (d String) Len() int {
+	return len(d)
+}
+
+// TestStore 对 newStore 创建的 Store 运行所有一致性测试
+func TestStore(t *testing.T, newStore lru.Policy) {
+	t.Run("Get", func(t *testing.T) { testGet(t, newStore) })
+	t.Run("Bytes", func(t *testing.T) { testBytes(t, newStore) })
+	t.Run("OnEvicted", func(t *testing.T) { testOnEvicted(t, newStore) })
+	t.Run("TTL", func(t *testing.T) { testTTL(t, newStore) })
+	t.Run("Remove", func(t *testing.T) { testRemove(t, newStore) })
+	t.Run("Contains", func(t *testing.T) { testContains(t, newStore) })
+}
+
+// testContains Contains 只报告 key 是否存在，不改变 Len 和 Bytes
+func testContains(t *testing.T, newStore lru.Policy) {
+	s := newStore(1<<10, nil)
+	s.Add("key1", String("1234"))
+	if !s.Contains("key1") || s.Contains("key2") {
+		t.Fatal("Contains should report key1 only")
+	}
+	if s.Len() != 1 || s.Bytes() != int64(len("key1")+len("1234")) {
+		t.Fatalf("Contains changed the store: %d items, %d bytes", s.Len(), s.Bytes())
+	}
+	s.Remove("key1")
+	if s.Contains("key1") {
+		t.Fatal("Contains should be false after Remove")
+	}
+}
+
+// testRemove 删除后不再命中、字节数随之减少，且不触发 OnEvicted
+func testRemove(t *testing.T, newStore lru.Policy) {
+	var evicted int
+	s := newStore(1<<10, func(string, lru.Value) { evicted++ })
+	s.Add("key1", String("1234"))
+	s.Add("key2", String("5678"))
+	s.Get("key2")
+	for _, key := range []string{"key1", "key2"} {
+		if !s.Remove(key) {
+			t.Fatalf("Remove(%s) = false, want true", key)
+		}
+		if _, ok := s.Get(key); ok {
+			t.Fatalf("%s still present after Remove", key)
+		}
+	}
+	if s.Remove("key1") {
+		t.Fatal("Remove of a missing key should return false")
+	}
+	if s.Len() != 0 || s.Bytes() != 0 || evicted != 0 {
+		t.Fatalf("expect empty store without evictions, got %d items, %d bytes, %d evicted", s.Len(), s.Bytes(), evicted)
+	}
+}
+
+func testGet(t *testing.T, newStore lru.Policy) {
+	s := newStore(0, nil)
+	s.Add("key1", String("1234"))
+	if v, ok := s.Get("key1"); !ok || string(v.(String)) != "1234" {
+		t.Fatalf("cache hit key1=1234 failed")
+	}
+	if _, ok := s.Get("key2"); ok {
+		t.Fatalf("cache miss key2 failed")
+	}
+}
+
+// testBytes 新增、更新、淘汰之后，Bytes 都应等于剩余记录的 key + value 长度之和
+func testBytes(t *testing.T, newStore lru.Policy) {
+	s := newStore(0, nil)
+	s.Add("key", String("1"))
+	s.Add("key", String("111"))
+	if s.Bytes() != int64(len("key")+len("111")) || s.Len() != 1 {
+		t.Fatalf("expected 6 bytes in 1 item, got %d in %d", s.Bytes(), s.Len())
+	}
+
+	const maxBytes = 20
+	var evicted int64
+	s = newStore(maxBytes, func(key string, value lru.Value) {
+		evicted += int64(len(key) + value.Len())
+	})
+	var added int64
+	for _, k := range []string{"k1", "k2", "k3", "k4", "k5", "k6", "k7"} {
+		s.Add(k, String("vvv"))
+		added += int64(len(k) + len("vvv"))
+		s.Get("k1") // 制造一些访问，让不同策略的淘汰顺序不同
+		if s.Bytes() > maxBytes {
+			t.Fatalf("bytes %d exceed maxBytes %d", s.Bytes(), maxBytes)
+		}
+	}
+	if s.Bytes()+evicted != added {
+		t.Fatalf("bytes %d + evicted %d != added %d", s.Bytes(), evicted, added)
+	}
+}
+
+// testOnEvicted 每条被淘汰的记录恰好回调一次，且之后无法再 Get 到
+func testOnEvicted(t *testing.T, newStore lru.Policy) {
+	evicted := make(map[string]int)
+	s := newStore(10, func(key string, value lru.Value) {
+		evicted[key]++
+	})
+	s.Add("key1", String("123456"))
+	s.Add("k2", String("k2"))
+	s.Add("k3", String("k3"))
+	s.Add("k4", String("k4"))
+
+	if len(evicted)+s.Len() != 4 {
+		t.Fatalf("evicted %d + remaining %d != 4", len(evicted), s.Len())
+	}
+	for k, n := range evicted {
+		if n != 1 {
+			t.Fatalf("OnEvicted called %d times for %s", n, k)
+		}
+		if _, ok := s.Get(k); ok {
+			t.Fatalf("evicted key %s still in store", k)
+		}
+	}
+	if _, ok := evicted["key1"]; !ok {
+		t.Fatalf("key1 should be evicted to make room")
+	}
+}
+
+// testTTL 过期记录在 Get 和 RemoveExpired 时被删除，字节数随之回收，淘汰原因为 lru.EvictExpired。
+// 所有 Store 都必须实现 lru.Clocked 和 lru.ReasonNotifier
+func testTTL(t *testing.T, newStore lru.Policy) {
+	var evicted []string
+	s := newStore(0, func(key string, value lru.Value) {
+		evicted = append(evicted, key)
+	})
+	now := time.Unix(0, 0)
+	s.(lru.Clocked).SetNow(func() time.Time { return now })
+	reasons := map[lru.EvictReason]int{}
+	s.(lru.ReasonNotifier).SetOnEvictedWithReason(func(key string, value lru.Value, reason lru.EvictReason) {
+		reasons[reason]++
+	})
+	s.AddWithTTL("k1", String("v1"), 20*time.Millisecond)
+	s.AddWithTTL("k2", String("v2"), 20*time.Millisecond)
+	s.Add("k3", String("v3"))
+
+	now = now.Add(19 * time.Millisecond)
+	if _, ok := s.Get("k1"); !ok {
+		t.Fatalf("k1 should not expire before its ttl")
+	}
+	now = now.Add(time.Millisecond)
+	if _, ok := s.Get("k1"); ok {
+		t.Fatalf("k1 should expire")
+	}
+	if freed := s.RemoveExpired(); freed != int64(len("k2")+len("v2")) {
+		t.Fatalf("expect 4 bytes freed, got %d", freed)
+	}
+	if s.Len() != 1 || s.Bytes() != int64(len("k3")+len("v3")) || len(evicted) != 2 {
+		t.Fatalf("unexpected state after expiry: len=%d bytes=%d evicted=%v", s.Len(), s.Bytes(), evicted)
+	}
+	if reasons[lru.EvictExpired] != 2 || reasons[lru.EvictCapacity] != 0 {
+		t.Fatalf("expect 2 expirations reported, got %v", reasons)
+	}
+}
@@ -0,0 +1,143 @@
+package geecache
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// latencyBuckets 是远程请求耗时直方图的上界（秒），与 Prometheus 客户端的默认桶一致
+var latencyBuckets = []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// histogram 是一个无锁的 Prometheus 直方图，只支持 observe 和渲染
+type histogram struct {
+	counts []uint64 // counts[i] 为落在 (latencyBuckets[i-1], latencyBuckets[i]] 内的次数，最后一个是 +Inf
+	count  uint64
+	sum    uint64 // float64 的位模式，用 CAS 累加
+}
+
+func newHistogram() *histogram {
+	return &histogram{counts: make([]uint64, len(latencyBuckets)+1)}
+}
+
+func (h *histogram) observe(d time.Duration) {
+	v := d.Seconds()
+	i := sort.SearchFloat64s(latencyBuckets, v)
+	atomic.AddUint64(&h.counts[i], 1)
+	atomic.AddUint64(&h.count, 1)
+	for {
+		old := atomic.LoadUint64(&h.sum)
+		sum := math.Float64bits(math.Float64frombits(old) + v)
+		if atomic.CompareAndSwapUint64(&h.sum, old, sum) {
+			return
+		}
+	}
+}
+
+// quantile 估算 q 分位数的耗时，在所在的桶内线性插值（与 Prometheus 的 histogram_quantile 相同）；
+// 落在 +Inf 桶时返回最大的有限上界
+func (h *histogram) quantile(q float64) time.Duration {
+	rank := q * float64(atomic.LoadUint64(&h.count))
+	var cum uint64
+	lower := 0.0
+	for i, upper := range latencyBuckets {
+		n := atomic.LoadUint64(&h.counts[i])
+		if n > 0 && float64(cum+n) >= rank {
+			v := lower + (upper-lower)*(rank-float64(cum))/float64(n)
+			return time.Duration(v * float64(time.Second))
+		}
+		cum += n
+		lower = upper
+	}
+	return time.Duration(lower * float64(time.Second))
+}
+
+// write 以 Prometheus 文本格式输出直方图，bucket 计数是累积的
+func (h *histogram) write(w io.Writer, name, labels string) {
+	var cum uint64
+	for i, le := range latencyBuckets {
+		cum += atomic.LoadUint64(&h.counts[i])
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"%g\"} %d\n", name, labels, le, cum)
+	}
+	cum += atomic.LoadUint64(&h.counts[len(latencyBuckets)])
+	fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, cum)
+	fmt.Fprintf(w, "%s_sum{%s} %g\n", name, labels, math.Float64frombits(atomic.LoadUint64(&h.sum)))
+	fmt.Fprintf(w, "%s_count{%s} %d\n", name, labels, atomic.LoadUint64(&h.count))
+}
+
+// MetricsHandler 返回一个以 Prometheus 文本格式输出指标的 http.Handler，
+// 包含每个 Group 的计数器、缓存字节数/条目数，以及 pool 的远程请求耗时直方图和哈希环大小。
+// pool 可以为 nil，此时只输出 Group 相关指标。用法：
+//
+//	http.Handle("/metrics", geecache.MetricsHandler(peers))
+func MetricsHandler(pool *HTTPPool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		writeGroupMetrics(w)
+		if pool != nil {
+			pool.writeMetrics(w)
+		}
+	})
+}
+
+// writeGroupMetrics 输出所有 Group 的统计信息，Group 按名称排序保证输出稳定
+func writeGroupMetrics(w io.Writer) {
+	all := allGroupStats()
+	names := make([]string, 0, len(all))
+	for name := range all {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	counters := []struct {
+		name, help string
+		value      func(*Stats) int64
+	}{
+		{"geecache_gets_total", "Total Get requests, including requests from peers.", func(s *Stats) int64 { return s.Gets.Get() }},
+		{"geecache_cache_hits_total", "Gets served from the main or hot cache.", func(s *Stats) int64 { return s.CacheHits.Get() }},
+		{"geecache_peer_loads_total", "Values successfully loaded from a remote peer.", func(s *Stats) int64 { return s.PeerLoads.Get() }},
+		{"geecache_peer_errors_total", "Failed loads from a remote peer.", func(s *Stats) int64 { return s.PeerErrors.Get() }},
+		{"geecache_local_loads_total", "Values successfully loaded by the Getter.", func(s *Stats) int64 { return s.LocalLoads.Get() }},
+		{"geecache_local_load_errors_total", "Failed loads by the Getter.", func(s *Stats) int64 { return s.LocalLoadErrs.Get() }},
+		{"geecache_server_requests_total", "Requests received from peers over HTTP.", func(s *Stats) int64 { return s.ServerRequests.Get() }},
+		{"geecache_sets_total", "Values written to the main cache by Set, including Sets from peers.", func(s *Stats) int64 { return s.Sets.Get() }},
+		{"geecache_peer_sets_total", "Sets successfully forwarded to the owning peer.", func(s *Stats) int64 { return s.PeerSets.Get() }},
+		{"geecache_removes_total", "Keys removed from this node's caches, including Removes from peers.", func(s *Stats) int64 { return s.Removes.Get() }},
+		{"geecache_not_founds_total", "Loads that ended with ErrNotFound from the Getter or a peer.", func(s *Stats) int64 { return s.NotFounds.Get() }},
+		{"geecache_negative_hits_total", "Gets answered with ErrNotFound from the negative cache.", func(s *Stats) int64 { return s.NegativeHits.Get() }},
+	}
+	for _, c := range counters {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+		for _, name := range names {
+			fmt.Fprintf(w, "%s{group=\"%s\"} %d\n", c.name, escapeLabel(name), c.value(all[name].Stats))
+		}
+	}
+
+	caches := []struct {
+		name, help, typ string
+		value           func(CacheStats) int64
+	}{
+		{"geecache_cache_bytes", "Bytes currently held by the cache.", "gauge", func(s CacheStats) int64 { return s.Bytes }},
+		{"geecache_cache_items", "Items currently held by the cache.", "gauge", func(s CacheStats) int64 { return s.Items }},
+		{"geecache_cache_evictions_total", "Items evicted from the cache.", "counter", func(s CacheStats) int64 { return s.Evictions }},
+	}
+	for _, c := range caches {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", c.name, c.help, c.name, c.typ)
+		for _, name := range names {
+			gs := all[name]
+			fmt.Fprintf(w, "%s{group=\"%s\",cache=\"main\"} %d\n", c.name, escapeLabel(name), c.value(gs.MainCache))
+			fmt.Fprintf(w, "%s{group=\"%s\",cache=\"hot\"} %d\n", c.name, escapeLabel(name), c.value(gs.HotCache))
+			fmt.Fprintf(w, "%s{group=\"%s\",cache=\"negative\"} %d\n", c.name, escapeLabel(name), c.value(gs.NegativeCache))
+		}
+	}
+}
+
+// escapeLabel 按 Prometheus 文本格式转义标签值
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
@@ -0,0 +1,61 @@
+package geecache
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistogram(t *testing.T) {
+	h := newHistogram()
+	h.observe(2 * time.Millisecond)
+	h.observe(20 * time.Millisecond)
+	h.observe(20 * time.Second)
+
+	var buf bytes.Buffer
+	h.write(&buf, "latency", `peer="a"`)
+	out := buf.String()
+	for _, line := range []string{
+		`latency_bucket{peer="a",le="0.001"} 0`,
+		`latency_bucket{peer="a",le="0.005"} 1`,
+		`latency_bucket{peer="a",le="0.025"} 2`,
+		`latency_bucket{peer="a",le="10"} 2`,
+		`latency_bucket{peer="a",le="+Inf"} 3`,
+		`latency_count{peer="a"} 3`,
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("missing %q in:\n%s", line, out)
+		}
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	gee := NewGroup("metrics", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) {
+			return []byte(db[key]), nil
+		}))
+	gee.Get("Tom")
+	gee.Get("Tom")
+
+	pool := NewHTTPPool("http://localhost:8001")
+	pool.Set("http://localhost:8001", "http://localhost:8002")
+	pool.latencies["http://localhost:8002"].observe(time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	MetricsHandler(pool).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	out := rec.Body.String()
+	for _, line := range []string{
+		"# TYPE geecache_gets_total counter",
+		`geecache_gets_total{group="metrics"} 2`,
+		`geecache_cache_hits_total{group="metrics"} 1`,
+		`geecache_cache_items{group="metrics",cache="main"} 1`,
+		`geecache_ring_peers{self="http://localhost:8001"} 2`,
+		`geecache_peer_request_duration_seconds_count{peer="http://localhost:8002"} 1`,
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("missing %q in:\n%s", line, out)
+		}
+	}
+}
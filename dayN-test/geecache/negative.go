@@ -0,0 +1,58 @@
+package geecache
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNotFound 表示数据源中不存在该 key。Getter 返回它（或用 %w 包装它的错误）时，
+// Group 不把它当作加载失败，而是在 WithNegativeCache 设置的时间内记住这个结果，
+// 期间对该 key 的 Get 直接返回错误而不再回源；节点之间以 HTTP 404 传递
+var ErrNotFound = errors.New("geecache: not found")
+
+// NotFoundError 是从负缓存或远程节点得到的"不存在"错误，保留了 Getter 原始的错误信息，
+// errors.Is(err, ErrNotFound) 为 true
+type NotFoundError struct {
+	Msg string
+}
+
+func (e *NotFoundError) Error() string { return e.Msg }
+
+func (e *NotFoundError) Unwrap() error { return ErrNotFound }
+
+// defaultNegativeCacheBytes 是 WithNegativeCache 未指定容量时负缓存的大小
+const defaultNegativeCacheBytes = 256 << 10
+
+// WithNegativeCache 开启负缓存：Getter 或远程节点返回 ErrNotFound 的 key 会被记住 ttl 时长，
+// 防止大量请求不存在的 key 时每次都穿透到数据源。负缓存按 LRU 淘汰，
+// 最多占用 maxBytes 字节（key 加错误信息），<= 0 时使用默认的 256KB；默认不开启。
+// Remove 会清除所有节点上的记录，Set 只清除本节点和负责节点上的记录
+func WithNegativeCache(ttl time.Duration, maxBytes int64) GroupOption {
+	return func(g *Group) {
+		if maxBytes <= 0 {
+			maxBytes = defaultNegativeCacheBytes
+		}
+		g.negCache = cache{cacheBytes: maxBytes, ttl: ttl}
+	}
+}
+
+// lookupNegative 返回负缓存中记录的"不存在"错误；未开启负缓存或未命中时返回 nil
+func (g *Group) lookupNegative(key string) error {
+	if g.negCache.ttl <= 0 {
+		return nil
+	}
+	v, ok := g.negCache.get(key)
+	if !ok {
+		return nil
+	}
+	return &NotFoundError{Msg: v.String()}
+}
+
+// recordNotFound 统计一次"不存在"的结果并写入负缓存，原样返回 err
+func (g *Group) recordNotFound(key string, err error) error {
+	g.Stats.NotFounds.Add(1)
+	if g.negCache.ttl > 0 {
+		g.negCache.add(key, ByteView{b: []byte(err.Error())})
+	}
+	return err
+}
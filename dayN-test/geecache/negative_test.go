@@ -0,0 +1,138 @@
+package geecache
+
+import (
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// notExist 是测试用的 Getter：只有 Tom 存在，其余 key 返回包装了 ErrNotFound 的错误
+func notExist(loads *int32) GetterFunc {
+	return func(key string) ([]byte, error) {
+		atomic.AddInt32(loads, 1)
+		if key == "Tom" {
+			return []byte("630"), nil
+		}
+		return nil, fmt.Errorf("%s not exist: %w", key, ErrNotFound)
+	}
+}
+
+func TestNegativeCache(t *testing.T) {
+	var loads int32
+	gee := newLocalGroup("negative", notExist(&loads))
+	WithNegativeCache(time.Minute, 0)(gee)
+
+	for i := 0; i < 3; i++ {
+		_, err := gee.Get("unknown")
+		if !errors.Is(err, ErrNotFound) || err.Error() != "unknown not exist: geecache: not found" {
+			t.Fatalf("expect ErrNotFound with the Getter's message, got %v", err)
+		}
+	}
+	if loads != 1 {
+		t.Fatalf("negative result should be cached, loaded %d times", loads)
+	}
+	if n, h, e := gee.Stats.NotFounds.Get(), gee.Stats.NegativeHits.Get(), gee.Stats.LocalLoadErrs.Get(); n != 1 || h != 2 || e != 0 {
+		t.Fatalf("unexpected stats: not_founds %d, negative_hits %d, local_load_errs %d", n, h, e)
+	}
+
+	// Set 和 Remove 都会清除负缓存
+	if err := gee.Set("unknown", []byte("v")); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := gee.Get("unknown"); err != nil || v.String() != "v" {
+		t.Fatalf("expect value after Set, got %q %v", v.String(), err)
+	}
+	gee.Remove("unknown")
+	gee.Get("unknown")
+	gee.Remove("unknown")
+	if _, err := gee.Get("unknown"); !errors.Is(err, ErrNotFound) || loads != 3 {
+		t.Fatalf("expect reload after Remove, got %v after %d loads", err, loads)
+	}
+}
+
+func TestNegativeCacheDisabled(t *testing.T) {
+	var loads int32
+	gee := newLocalGroup("negative-disabled", notExist(&loads))
+	for i := 0; i < 2; i++ {
+		if _, err := gee.Get("unknown"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expect ErrNotFound, got %v", err)
+		}
+	}
+	if loads != 2 || gee.Stats.NotFounds.Get() != 2 || gee.CacheStats(NegativeCache).Items != 0 {
+		t.Fatalf("negative cache should be off by default, loaded %d times", loads)
+	}
+}
+
+func TestNegativeCacheExpire(t *testing.T) {
+	var loads int32
+	gee := newLocalGroup("negative-expire", notExist(&loads))
+	WithNegativeCache(20*time.Millisecond, 0)(gee)
+
+	gee.Get("unknown")
+	gee.Get("unknown")
+	time.Sleep(50 * time.Millisecond)
+	gee.Get("unknown")
+	if loads != 2 {
+		t.Fatalf("expect a reload after the negative ttl, loaded %d times", loads)
+	}
+}
+
+// TestNegativeCacheBounded 负缓存按 maxBytes 淘汰，不会无限增长
+func TestNegativeCacheBounded(t *testing.T) {
+	var loads int32
+	gee := newLocalGroup("negative-bounded", notExist(&loads))
+	WithNegativeCache(time.Minute, 1<<10)(gee)
+
+	for i := 0; i < 100; i++ {
+		gee.Get(fmt.Sprintf("key-%d", i))
+	}
+	if st := gee.CacheStats(NegativeCache); st.Bytes > 1<<10 || st.Evictions == 0 {
+		t.Fatalf("expect negative cache bounded by 1KB, got %+v", st)
+	}
+}
+
+// TestHTTPNotFound 负责节点返回 404 + notFoundHeader，请求方得到 ErrNotFound 并缓存；
+// 不重试、不转移到接替节点、不回退到本地加载，也不算节点故障
+func TestHTTPNotFound(t *testing.T) {
+	var remoteLoads int32
+	NewGroup("negative-cluster", 2<<10, notExist(&remoteLoads), WithNegativeCache(time.Minute, 0))
+	srv := httptest.NewServer(NewHTTPPool(""))
+	defer srv.Close()
+
+	pool := NewHTTPPool("http://self")
+	pool.SetCircuitBreaker(&CircuitBreakerOptions{Window: time.Minute, MinRequests: 1, FailureRate: 1, Cooldown: time.Minute})
+	pool.SetRetryPolicy(&DefaultRetryPolicy)
+	pool.Set(srv.URL)
+	var localLoads int32
+	local := newLocalGroup("negative-cluster", notExist(&localLoads))
+	WithNegativeCache(time.Minute, 0)(local)
+	local.RegisterPeers(pool)
+
+	for i := 0; i < 2; i++ {
+		_, err := local.Get("unknown")
+		if !errors.Is(err, ErrNotFound) || err.Error() != "unknown not exist: geecache: not found" {
+			t.Fatalf("expect ErrNotFound from peer, got %v", err)
+		}
+	}
+	if remoteLoads != 1 || localLoads != 0 {
+		t.Fatalf("expect one remote load and no local load, got %d and %d", remoteLoads, localLoads)
+	}
+	if local.Stats.NotFounds.Get() != 1 || local.Stats.NegativeHits.Get() != 1 || local.Stats.PeerErrors.Get() != 0 {
+		t.Fatalf("unexpected stats: %+v", &local.Stats)
+	}
+	if st := pool.PeerStats()[srv.URL]; st.State != "closed" {
+		t.Fatalf("404 should not open the breaker, got %+v", st)
+	}
+
+	// 批量接口同样传递 ErrNotFound
+	values, errs := local.GetMany([]string{"Tom", "Jack"})
+	if values["Tom"].String() != "630" || !errors.Is(errs["Jack"], ErrNotFound) {
+		t.Fatalf("unexpected batch result: %v %v", values, errs)
+	}
+	if _, err := local.Get("Jack"); !errors.Is(err, ErrNotFound) || localLoads != 0 {
+		t.Fatalf("expect Jack in the negative cache, got %v", err)
+	}
+}
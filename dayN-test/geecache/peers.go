@@ -4,6 +4,12 @@
 
 package geecache
 
+import (
+	"context"
+	"errors"
+	"time"
+)
+
 // PeerPicker接口 根据 key，选出负责该 key 的「远程对等节点 PeerGetter」（选点）
 // 将"选点"逻辑抽象成接口，后续可灵活替换一致性哈希、简单轮询或其他策略
 // - key: 要查找的缓存键
@@ -15,6 +21,28 @@ type PeerPicker interface {
 	PickPeer(key string) (peer PeerGetter, ok bool)
 }
 
+// ReplicaPicker 由能给出多个候选远程节点的 PeerPicker 实现：
+// 第一个是负责该 key 的节点，其后是它故障时依次接替的节点。
+// 返回空切片表示应由本节点处理
+type ReplicaPicker interface {
+	PeerPicker
+	PickPeers(key string) []PeerGetter
+}
+
+// BatchPeerPicker 由 PickPeer 会按 key 包装 PeerGetter（如对冲）的 PeerPicker 实现：
+// 选出与 PickPeer 相同的节点，但返回不带按 key 包装的客户端，发往同一节点的 key 得到相等的 peer。
+// Group.GetMany 以 peer 为键把 key 分组，每个节点只发一次批量请求；未实现时使用 PickPeer
+type BatchPeerPicker interface {
+	PickBatchPeer(key string) (peer PeerGetter, ok bool)
+}
+
+// OwnerPicker 由 PickPeer 可能绕开负责节点（有界负载、熔断）的 PeerPicker 实现：
+// 返回 key 在哈希环上的负责节点，负责节点是本节点时 ok 为 false。
+// Group.Set 据此写入，值只会写到负责节点，不会被写到接替节点或本节点的 mainCache；未实现时使用 PickPeer
+type OwnerPicker interface {
+	PickOwner(key string) (peer PeerGetter, ok bool)
+}
+
 // PeerGetter接口 定义了从远程缓存节点获取缓存值的方法抽象（取值）
 // 各种网络客户端（如 httpGetter 或者 gRPC 客户端）需实现此接口，以便完成跨节点的数据访问
 // 解耦网络传输细节，Group 只需调用此接口获取值，无需关心底层是 HTTP 还是 RPC
@@ -25,3 +53,86 @@ type PeerPicker interface {
 type PeerGetter interface {
 	Get(group string, key string) ([]byte, error)
 }
+
+// BatchPeerGetter 由能一次拉取多个 key 的 PeerGetter 实现，供 Group.GetMany 使用。
+// values 和 errs 以 key 为索引，请求整体失败时每个 key 都应在 errs 中有对应的错误
+type BatchPeerGetter interface {
+	GetMany(ctx context.Context, group string, keys []string) (values map[string][]byte, errs map[string]error)
+}
+
+// TTLPeerGetter 由能给出值有效期的 PeerGetter 实现：ttl 是值在远程节点缓存中的剩余有效期，0 表示不过期。
+// Group 把拉取到的值写入 hotCache 时不超过这个有效期，避免本地副本比远程节点上的值活得更久
+type TTLPeerGetter interface {
+	GetWithTTL(ctx context.Context, group string, key string) (value []byte, ttl time.Duration, err error)
+}
+
+// PeerSetter 由支持写入的 PeerGetter 实现：把 value 写入远程节点（key 的负责节点）的 mainCache，
+// 供 Group.Set 使用
+type PeerSetter interface {
+	Set(ctx context.Context, group string, key string, value []byte) error
+}
+
+// PeerRemover 由支持删除的 PeerGetter 实现：从远程节点的 mainCache 和 hotCache 中删除 key，
+// 供 Group.Remove 使用
+type PeerRemover interface {
+	Remove(ctx context.Context, group string, key string) error
+}
+
+// PeerLister 由能列出所有远程节点的 PeerPicker 实现（节点地址 -> PeerGetter，不含本节点），
+// Group.Remove 据此把删除广播给所有节点，清掉它们 hotCache 中的副本
+type PeerLister interface {
+	ListPeers() map[string]PeerGetter
+}
+
+// ContextPeerGetter 是带 context 的 PeerGetter，超时与取消会随请求传递到远程节点
+type ContextPeerGetter interface {
+	GetContext(ctx context.Context, group string, key string) ([]byte, error)
+}
+
+// peerGetterAdapter 把旧的 PeerGetter 适配为 ContextPeerGetter，ctx 被忽略
+type peerGetterAdapter struct {
+	PeerGetter
+}
+
+func (a peerGetterAdapter) GetContext(_ context.Context, group string, key string) ([]byte, error) {
+	return a.Get(group, key)
+}
+
+// toContextPeerGetter 若 peer 已实现 ContextPeerGetter 则直接使用，否则包一层适配器
+func toContextPeerGetter(peer PeerGetter) ContextPeerGetter {
+	if cp, ok := peer.(ContextPeerGetter); ok {
+		return cp
+	}
+	return peerGetterAdapter{peer}
+}
+
+// noForwardKey 是 context 中"不要再转发给其他节点"标记的 key
+type noForwardKey struct{}
+
+// WithNoForward 标记本次请求只能在收到它的节点上加载。
+// 故障转移时请求会发往并不负责该 key 的接替节点，它不应再按哈希环转发回已故障的节点。
+// HTTPPool 以外的传输层（如 grpcpeers）在收到这类请求时也需要用它标记 ctx
+func WithNoForward(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noForwardKey{}, true)
+}
+
+// NoForward 判断 ctx 是否带有 WithNoForward 的标记，传输层据此告知远程节点不要再转发
+func NoForward(ctx context.Context) bool {
+	v, _ := ctx.Value(noForwardKey{}).(bool)
+	return v
+}
+
+// ErrRemoteLoad 表示远程节点收到了请求，但它的 Getter 加载失败。
+// 这是数据源的问题而不是节点故障：Group 直接返回错误，不再尝试接替节点或本地回调，
+// 熔断器和重试也不把它算作节点故障
+var ErrRemoteLoad = errors.New("geecache: remote load failed")
+
+// RemoteLoadError 是传输层收到的"远程节点加载失败"的错误，保留了远程 Getter 原始的错误信息，
+// errors.Is(err, ErrRemoteLoad) 为 true
+type RemoteLoadError struct {
+	Msg string
+}
+
+func (e *RemoteLoadError) Error() string { return e.Msg }
+
+func (e *RemoteLoadError) Unwrap() error { return ErrRemoteLoad }
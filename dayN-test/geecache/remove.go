@@ -0,0 +1,91 @@
+package geecache
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RemoveError 由 Group.Remove 返回，列出删除失败的远程节点。
+// 本节点的删除总会成功，失败的节点上可能还留着旧值，直到它过期或被淘汰
+type RemoveError struct {
+	Failed map[string]error // 节点地址 -> 错误；PeerPicker 不支持 PeerLister 时键为 "owner"
+}
+
+func (e *RemoveError) Error() string {
+	peers := make([]string, 0, len(e.Failed))
+	for peer := range e.Failed {
+		peers = append(peers, peer)
+	}
+	sort.Strings(peers)
+	msgs := make([]string, len(peers))
+	for i, peer := range peers {
+		msgs[i] = fmt.Sprintf("%s: %v", peer, e.Failed[peer])
+	}
+	return fmt.Sprintf("geecache: remove failed on %d peer(s): %s", len(peers), strings.Join(msgs, "; "))
+}
+
+// Remove 在整个集群中删除 key，等价于 RemoveContext(context.Background(), key)
+func (g *Group) Remove(key string) error {
+	return g.RemoveContext(context.Background(), key)
+}
+
+// RemoveContext 在整个集群中删除 key，用于数据源中的值变化后让缓存失效：
+//  1. 删除本节点 mainCache、hotCache 和负缓存中的 key；
+//  2. 若 PeerPicker 实现了 PeerLister，并发地向所有远程节点发出删除，
+//     负责节点删掉 mainCache 中的值，其他节点删掉 hotCache 中的副本；
+//     否则只向 PickPeer 选出的负责节点发出删除。
+//
+// 有节点删除失败时返回 *RemoveError。
+// 注意：与删除并发进行的加载仍可能把旧值写回缓存
+func (g *Group) RemoveContext(ctx context.Context, key string) error {
+	if key == "" {
+		return fmt.Errorf("key is required")
+	}
+	g.Stats.Removes.Add(1)
+	g.mainCache.remove(key)
+	g.hotCache.remove(key)
+	g.negCache.remove(key)
+
+	if g.peers == nil || NoForward(ctx) {
+		return nil
+	}
+	// 收到删除的节点只在本地删除，不再广播
+	ctx = WithNoForward(ctx)
+
+	targets := map[string]PeerGetter{}
+	if pl, ok := g.peers.(PeerLister); ok {
+		targets = pl.ListPeers()
+	} else if peer, ok := g.peers.PickPeer(key); ok {
+		targets["owner"] = peer
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	failed := make(map[string]error)
+	for name, peer := range targets {
+		wg.Add(1)
+		go func(name string, peer PeerGetter) {
+			defer wg.Done()
+			var err error
+			if pr, ok := peer.(PeerRemover); ok {
+				err = pr.Remove(ctx, g.name, key)
+			} else {
+				err = fmt.Errorf("peer %T does not support Remove", peer)
+			}
+			if err != nil {
+				mu.Lock()
+				failed[name] = err
+				mu.Unlock()
+			}
+		}(name, peer)
+	}
+	wg.Wait()
+
+	if len(failed) > 0 {
+		return &RemoveError{Failed: failed}
+	}
+	return nil
+}
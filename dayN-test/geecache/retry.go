@@ -0,0 +1,81 @@
+package geecache
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy 是远程请求的重试策略。
+// 第 n 次重试前等待 BaseDelay*2^(n-1)（不超过 MaxDelay），并在 [d/2, d] 内随机抖动，
+// 避免大量客户端在同一时刻重试同一个节点
+type RetryPolicy struct {
+	MaxAttempts     int           // 最多尝试次数（含第一次），<= 1 表示不重试
+	BaseDelay       time.Duration // 第一次重试前的等待时长
+	MaxDelay        time.Duration // 单次等待的上限，0 表示不限制
+	RetryableStatus []int         // 可重试的 HTTP 状态码；网络错误总是可重试
+}
+
+// DefaultRetryPolicy 最多尝试 3 次，对 502/503/504 和网络错误重试
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:     3,
+	BaseDelay:       10 * time.Millisecond,
+	MaxDelay:        200 * time.Millisecond,
+	RetryableStatus: []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+}
+
+// do 按策略执行 fn，直到成功、遇到不可重试的错误、次数用完或 ctx 结束
+func (rp *RetryPolicy) do(ctx context.Context, fn func() ([]byte, error)) ([]byte, error) {
+	for attempt := 1; ; attempt++ {
+		value, err := fn()
+		if err == nil || attempt >= rp.MaxAttempts || !rp.retryable(ctx, err) {
+			return value, err
+		}
+		delay := rp.backoff(attempt)
+		// 剩余时间不够等到下一次重试，直接返回本次的错误
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return nil, err
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// retryable 判断 err 是否值得重试：调用方取消、熔断、key 不存在、远程 Getter 失败和 4xx 等确定性的错误不重试
+func (rp *RetryPolicy) retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, errCircuitOpen) || errors.Is(err, ErrNotFound) || errors.Is(err, ErrRemoteLoad) {
+		return false
+	}
+	var se *statusError
+	if errors.As(err, &se) {
+		for _, code := range rp.RetryableStatus {
+			if se.code == code {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// backoff 返回第 attempt 次失败后、下一次重试前的等待时长
+func (rp *RetryPolicy) backoff(attempt int) time.Duration {
+	d := rp.BaseDelay
+	for i := 1; i < attempt && (rp.MaxDelay <= 0 || d < rp.MaxDelay); i++ {
+		d *= 2
+	}
+	if rp.MaxDelay > 0 && d > rp.MaxDelay {
+		d = rp.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
@@ -0,0 +1,67 @@
+package geecache
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// statusTransport 依次返回 codes 中的状态码，用完后一直返回 200
+func statusTransport(calls *int32, codes ...int) http.RoundTripper {
+	return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		code := http.StatusOK
+		if n := int(atomic.AddInt32(calls, 1)); n <= len(codes) {
+			code = codes[n-1]
+		}
+		return &http.Response{
+			StatusCode: code,
+			Status:     http.StatusText(code),
+			Body:       io.NopCloser(strings.NewReader("v")),
+			Request:    req,
+		}, nil
+	})
+}
+
+func TestRetryPolicy(t *testing.T) {
+	rp := DefaultRetryPolicy
+	rp.BaseDelay = time.Millisecond
+
+	var calls int32
+	h := &httpGetter{baseURL: "http://a" + defaultBasePath, retry: &rp,
+		transport: statusTransport(&calls, http.StatusServiceUnavailable, http.StatusBadGateway)}
+	if v, err := h.Get("scores", "Tom"); err != nil || string(v) != "v" {
+		t.Fatalf("expect success after retries, got %q %v", v, err)
+	}
+	if calls != 3 {
+		t.Fatalf("expect 3 attempts, got %d", calls)
+	}
+
+	// 次数用完后返回最后一次的错误
+	calls = 0
+	h.transport = statusTransport(&calls, 503, 503, 503, 503)
+	if _, err := h.Get("scores", "Tom"); err == nil || calls != 3 {
+		t.Fatalf("expect failure after 3 attempts, got %v after %d", err, calls)
+	}
+
+	// 4xx 不重试
+	calls = 0
+	h.transport = statusTransport(&calls, http.StatusNotFound)
+	if _, err := h.Get("scores", "Tom"); err == nil || calls != 1 {
+		t.Fatalf("404 should not be retried, got %v after %d", err, calls)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	rp := RetryPolicy{BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}
+	for attempt, want := range map[int]time.Duration{1: 10, 2: 20, 3: 40, 4: 50, 10: 50} {
+		want *= time.Millisecond
+		for i := 0; i < 20; i++ {
+			if d := rp.backoff(attempt); d < want/2 || d > want {
+				t.Fatalf("backoff(%d) = %v, want in [%v, %v]", attempt, d, want/2, want)
+			}
+		}
+	}
+}
@@ -1,14 +1,133 @@
-package slingflight
+// 实现了请求合并：相同 key 的并发调用只会真正执行一次 fn，其余调用等待并共享结果
+package singleflight
 
-import "sync"
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
 
+// errGoexit 表示 fn 调用了 runtime.Goexit，没有正常返回
+var errGoexit = errors.New("singleflight: fn called runtime.Goexit")
+
+// PanicError 表示 fn 发生了 panic，Value 是 panic 的值，Stack 是发生 panic 时的调用栈
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("singleflight: fn panicked: %v\n\n%s", e.Value, e.Stack)
+}
+
+// call 表示某个 key 的一次正在执行或已完成的函数调用
 type call struct {
 	wg  sync.WaitGroup
 	val interface{}
 	err error
+
+	dups  int             // 除发起者外，共享这次结果的调用次数
+	chans []chan<- Result // DoChan 的等待者
+}
+
+// Result 是 DoChan 通过 channel 返回的结果
+// Shared 表示该结果是否被多个调用者共享
+type Result struct {
+	Val    interface{}
+	Err    error
+	Shared bool
 }
 
+// Group 管理所有正在执行的 call，同一个 key 同一时间最多只存在一个 *call
 type Group struct {
-	wg sync.Mutex
-	m  map[string]*call
+	mu sync.Mutex
+	m  map[string]*call // 懒加载
+}
+
+// Do 针对相同的 key，无论 Do 被并发调用多少次，fn 都只会被调用一次，
+// 所有调用者等待 fn 结束后拿到同一份返回值或错误。
+// fn panic 时，等待者得到 *PanicError，执行 fn 的调用者重新 panic
+func (g *Group) Do(key string, fn func() (interface{}, error)) (interface{}, error) {
+	g.mu.Lock()
+	if g.m == nil {
+		g.m = make(map[string]*call)
+	}
+	if c, ok := g.m[key]; ok {
+		c.dups++
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.val, c.err
+	}
+	c := new(call)
+	c.wg.Add(1)
+	g.m[key] = c
+	g.mu.Unlock()
+
+	g.doCall(c, key, fn)
+	if pe, ok := c.err.(*PanicError); ok {
+		panic(pe.Value)
+	}
+	return c.val, c.err
+}
+
+// DoChan 与 Do 相同，但不阻塞调用者，结果就绪后写入返回的 channel。
+// fn 在后台 goroutine 中执行，panic 不会被重新抛出（否则会让整个进程退出），
+// 而是以 *PanicError 交给所有等待者
+func (g *Group) DoChan(key string, fn func() (interface{}, error)) <-chan Result {
+	ch := make(chan Result, 1)
+	g.mu.Lock()
+	if g.m == nil {
+		g.m = make(map[string]*call)
+	}
+	if c, ok := g.m[key]; ok {
+		c.dups++
+		c.chans = append(c.chans, ch)
+		g.mu.Unlock()
+		return ch
+	}
+	c := &call{chans: []chan<- Result{ch}}
+	c.wg.Add(1)
+	g.m[key] = c
+	g.mu.Unlock()
+
+	go g.doCall(c, key, fn)
+	return ch
+}
+
+// doCall 执行 fn，通知所有等待者，并清理 m[key]。
+// 清理放在 defer 中，fn panic 或调用 runtime.Goexit 时等待者也不会永远阻塞
+func (g *Group) doCall(c *call, key string, fn func() (interface{}, error)) {
+	returned := false
+	defer func() {
+		if !returned {
+			if r := recover(); r != nil {
+				c.val, c.err = nil, &PanicError{Value: r, Stack: debug.Stack()}
+			} else {
+				c.val, c.err = nil, errGoexit
+			}
+		}
+
+		g.mu.Lock()
+		c.wg.Done()
+		// 只有当 m[key] 仍是本次 call 时才删除，避免误删 Forget 之后新建的 call
+		if g.m[key] == c {
+			delete(g.m, key)
+		}
+		for _, ch := range c.chans {
+			ch <- Result{Val: c.val, Err: c.err, Shared: c.dups > 0}
+		}
+		g.mu.Unlock()
+	}()
+
+	c.val, c.err = fn()
+	returned = true
+}
+
+// Forget 让 Group 忘记某个 key，之后对该 key 的调用会重新执行 fn，
+// 而不是等待仍在进行中的那一次调用
+func (g *Group) Forget(key string) {
+	g.mu.Lock()
+	delete(g.m, key)
+	g.mu.Unlock()
 }
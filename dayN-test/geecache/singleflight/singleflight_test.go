@@ -0,0 +1,153 @@
+package singleflight
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDo(t *testing.T) {
+	var g Group
+	v, err := g.Do("key", func() (interface{}, error) {
+		return "bar", nil
+	})
+	if v != "bar" || err != nil {
+		t.Fatalf("Do v = %v, err = %v", v, err)
+	}
+}
+
+func TestDoErr(t *testing.T) {
+	var g Group
+	someErr := errors.New("some error")
+	v, err := g.Do("key", func() (interface{}, error) {
+		return nil, someErr
+	})
+	if err != someErr || v != nil {
+		t.Fatalf("Do v = %v, err = %v", v, err)
+	}
+}
+
+// TestDoDupSuppress 并发调用同一个 key，fn 只应被执行一次
+func TestDoDupSuppress(t *testing.T) {
+	var g Group
+	var calls int32
+	release := make(chan struct{})
+	fn := func() (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release // 阻塞住，保证其余调用都进入等待
+		return "bar", nil
+	}
+
+	const n = 10
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := g.Do("key", fn)
+			if v != "bar" || err != nil {
+				t.Errorf("Do v = %v, err = %v", v, err)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("number of calls = %d; want 1", got)
+	}
+}
+
+func TestDoChan(t *testing.T) {
+	var g Group
+	release := make(chan struct{})
+	ch1 := g.DoChan("key", func() (interface{}, error) {
+		<-release
+		return "bar", nil
+	})
+	ch2 := g.DoChan("key", func() (interface{}, error) {
+		t.Error("second fn should not be called")
+		return nil, nil
+	})
+	close(release)
+
+	for _, ch := range []<-chan Result{ch1, ch2} {
+		res := <-ch
+		if res.Val != "bar" || res.Err != nil || !res.Shared {
+			t.Fatalf("DoChan result = %+v", res)
+		}
+	}
+}
+
+// TestForget Forget 之后，对同一 key 的调用不再等待旧的 call
+func TestForget(t *testing.T) {
+	var g Group
+	release := make(chan struct{})
+	started := make(chan struct{})
+	ch := g.DoChan("key", func() (interface{}, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+	g.Forget("key")
+
+	v, _ := g.Do("key", func() (interface{}, error) {
+		return 2, nil
+	})
+	if v != 2 {
+		t.Fatalf("Do after Forget = %v; want 2", v)
+	}
+	close(release)
+	if res := <-ch; res.Val != 1 {
+		t.Fatalf("first call result = %v; want 1", res.Val)
+	}
+}
+
+// TestDoPanic fn panic 时，发起者重新 panic，等待者拿到 *PanicError，key 被清理
+func TestDoPanic(t *testing.T) {
+	var g Group
+	release := make(chan struct{})
+	started := make(chan struct{})
+	panicked := make(chan interface{}, 1)
+	go func() {
+		defer func() { panicked <- recover() }()
+		g.Do("key", func() (interface{}, error) {
+			close(started)
+			<-release
+			panic("boom")
+		})
+	}()
+	<-started
+	ch := g.DoChan("key", func() (interface{}, error) {
+		t.Error("waiter's fn should not be called")
+		return nil, nil
+	})
+	close(release)
+
+	if r := <-panicked; r != "boom" {
+		t.Fatalf("expect caller to re-panic with boom, got %v", r)
+	}
+	var pe *PanicError
+	if res := <-ch; !errors.As(res.Err, &pe) || pe.Value != "boom" {
+		t.Fatalf("expect PanicError for waiter, got %+v", res)
+	}
+	if v, err := g.Do("key", func() (interface{}, error) { return "bar", nil }); v != "bar" || err != nil {
+		t.Fatalf("key should be released after panic, got %v %v", v, err)
+	}
+}
+
+// TestDoChanPanic DoChan 中 fn panic 不会让进程退出，所有等待者拿到 *PanicError
+func TestDoChanPanic(t *testing.T) {
+	var g Group
+	res := <-g.DoChan("key", func() (interface{}, error) {
+		panic("boom")
+	})
+	var pe *PanicError
+	if !errors.As(res.Err, &pe) || pe.Value != "boom" || len(pe.Stack) == 0 {
+		t.Fatalf("expect PanicError, got %+v", res)
+	}
+}
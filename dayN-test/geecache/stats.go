@@ -0,0 +1,92 @@
+package geecache
+
+import (
+	"strconv"
+	"sync/atomic"
+)
+
+// AtomicInt 是可并发读写的 int64 计数器
+type AtomicInt int64
+
+// Add 原子地把 n 加到 i 上
+func (i *AtomicInt) Add(n int64) {
+	atomic.AddInt64((*int64)(i), n)
+}
+
+// Get 原子地读取 i 的值
+func (i *AtomicInt) Get() int64 {
+	return atomic.LoadInt64((*int64)(i))
+}
+
+func (i *AtomicInt) String() string {
+	return strconv.FormatInt(i.Get(), 10)
+}
+
+// MarshalJSON 原子地读取后编码，使 Stats 可以直接 json.Marshal
+func (i *AtomicInt) MarshalJSON() ([]byte, error) {
+	return strconv.AppendInt(nil, i.Get(), 10), nil
+}
+
+// Stats 是每个 Group 的统计计数器
+type Stats struct {
+	Gets           AtomicInt `json:"gets"`            // 所有 Get 请求（包括来自其他节点的）
+	CacheHits      AtomicInt `json:"cache_hits"`      // mainCache 或 hotCache 命中
+	PeerLoads      AtomicInt `json:"peer_loads"`      // 从远程节点成功拉取
+	PeerErrors     AtomicInt `json:"peer_errors"`     // 从远程节点拉取失败
+	LocalLoads     AtomicInt `json:"local_loads"`     // 调用 Getter 成功
+	LocalLoadErrs  AtomicInt `json:"local_load_errs"` // 调用 Getter 失败
+	ServerRequests AtomicInt `json:"server_requests"` // 通过 HTTP 收到的来自其他节点的请求
+	Sets           AtomicInt `json:"sets"`            // 写入本节点 mainCache 的 Set（包括来自其他节点的）
+	PeerSets       AtomicInt `json:"peer_sets"`       // 成功转发给远程负责节点的 Set
+	Removes        AtomicInt `json:"removes"`         // 在本节点执行的 Remove（包括来自其他节点的）
+	NotFounds      AtomicInt `json:"not_founds"`      // Getter 或远程节点返回 ErrNotFound
+	NegativeHits   AtomicInt `json:"negative_hits"`   // 负缓存命中，直接返回 ErrNotFound
+}
+
+// CacheType 表示 Group 中的哪一个缓存
+type CacheType int
+
+const (
+	MainCache     CacheType = iota + 1 // 本节点负责的 key
+	HotCache                           // 从远程节点拉取的热点 key
+	NegativeCache                      // 不存在的 key，见 WithNegativeCache
+)
+
+// CacheStats 返回指定缓存的统计快照
+func (g *Group) CacheStats(which CacheType) CacheStats {
+	switch which {
+	case MainCache:
+		return g.mainCache.stats()
+	case HotCache:
+		return g.hotCache.stats()
+	case NegativeCache:
+		return g.negCache.stats()
+	default:
+		return CacheStats{}
+	}
+}
+
+// groupStats 是 /_geecache/_stats 返回的单个 Group 的统计信息
+type groupStats struct {
+	Stats         *Stats     `json:"stats"`
+	MainCache     CacheStats `json:"main_cache"`
+	HotCache      CacheStats `json:"hot_cache"`
+	NegativeCache CacheStats `json:"negative_cache"`
+}
+
+// allGroupStats 收集所有已注册 Group 的统计信息，key 为 Group 名称
+func allGroupStats() map[string]groupStats {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	m := make(map[string]groupStats, len(groups))
+	for name, g := range groups {
+		m[name] = groupStats{
+			Stats:         &g.Stats,
+			MainCache:     g.CacheStats(MainCache),
+			HotCache:      g.CacheStats(HotCache),
+			NegativeCache: g.CacheStats(NegativeCache),
+		}
+	}
+	return m
+}
@@ -0,0 +1,224 @@
+package tcppeers
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"geecache"
+	"net"
+	"sync"
+	"time"
+)
+
+var errConnClosed = errors.New("tcppeers: connection closed")
+
+// tcpGetter 通过一条长连接向远程节点拉取缓存，连接断开后下一次请求时重连
+type tcpGetter struct {
+	addr string
+	dial func(addr string) (net.Conn, error)
+
+	mu     sync.Mutex
+	conn   *clientConn
+	closed bool
+}
+
+func (g *tcpGetter) Get(group string, key string) ([]byte, error) {
+	return g.GetContext(context.Background(), group, key)
+}
+
+// GetContext 在共享的连接上发起一次请求；ctx 的剩余时长随请求发给远程节点
+func (g *tcpGetter) GetContext(ctx context.Context, group string, key string) ([]byte, error) {
+	cc, err := g.getConn()
+	if err != nil {
+		return nil, err
+	}
+	req := &request{group: group, key: key}
+	if geecache.NoForward(ctx) {
+		req.flags |= flagNoForward
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		ms := time.Until(deadline).Milliseconds()
+		if ms <= 0 {
+			return nil, context.DeadlineExceeded
+		}
+		req.timeout = uint32(min(ms, int64(^uint32(0))))
+	}
+	return cc.roundTrip(ctx, req)
+}
+
+// getConn 返回可用的连接，没有或已断开时重新建立
+func (g *tcpGetter) getConn() (*clientConn, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.closed {
+		return nil, errConnClosed
+	}
+	if g.conn != nil && !g.conn.broken() {
+		return g.conn, nil
+	}
+	nc, err := g.dial(g.addr)
+	if err != nil {
+		return nil, err
+	}
+	g.conn = newClientConn(nc)
+	return g.conn, nil
+}
+
+func (g *tcpGetter) close() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.closed = true
+	if g.conn != nil {
+		g.conn.fail(errConnClosed)
+	}
+}
+
+// clientConn 是一条复用的连接：写请求时加锁，后台 goroutine 读取响应并按 id 交给等待者
+type clientConn struct {
+	nc net.Conn
+
+	wmu sync.Mutex // 保护 w
+	w   *bufio.Writer
+
+	mu      sync.Mutex // 保护以下字段
+	nextID  uint64
+	pending map[uint64]chan *response
+	err     error // 非 nil 表示连接已断开
+}
+
+func newClientConn(nc net.Conn) *clientConn {
+	cc := &clientConn{
+		nc:      nc,
+		w:       bufio.NewWriter(nc),
+		pending: make(map[uint64]chan *response),
+	}
+	go cc.readLoop()
+	return cc
+}
+
+// roundTrip 发送请求并等待对应 id 的响应。
+// 请求本身不合法（如 key 太大）时直接返回错误，不影响连接上的其他请求；只有写连接出错才断开连接
+func (cc *clientConn) roundTrip(ctx context.Context, req *request) ([]byte, error) {
+	if err := checkRequest(req); err != nil {
+		return nil, err
+	}
+	ch := make(chan *response, 1)
+	cc.mu.Lock()
+	if cc.err != nil {
+		cc.mu.Unlock()
+		return nil, cc.err
+	}
+	cc.nextID++
+	req.id = cc.nextID
+	cc.pending[req.id] = ch
+	cc.mu.Unlock()
+
+	cc.wmu.Lock()
+	err := writeRequest(cc.w, req)
+	if err == nil {
+		err = cc.w.Flush()
+	}
+	cc.wmu.Unlock()
+	if err != nil {
+		cc.fail(err)
+		return nil, err
+	}
+
+	select {
+	case res := <-ch:
+		if res == nil {
+			return nil, cc.failure()
+		}
+		return decodeResponse(res)
+	case <-ctx.Done():
+		// 迟到的响应由 readLoop 丢弃
+		cc.mu.Lock()
+		delete(cc.pending, req.id)
+		cc.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// decodeResponse 把响应转换为值或错误
+func decodeResponse(res *response) ([]byte, error) {
+	switch res.status {
+	case statusOK:
+		return res.payload, nil
+	case statusTimeout:
+		return nil, context.DeadlineExceeded
+	case statusKeyNotFound:
+		return nil, &geecache.NotFoundError{Msg: string(res.payload)}
+	}
+	return nil, &RemoteError{Msg: string(res.payload), NotFound: res.status == statusNotFound}
+}
+
+// RemoteError 是远程节点返回的错误
+type RemoteError struct {
+	Msg      string
+	NotFound bool // group 在远程节点上不存在
+}
+
+func (e *RemoteError) Error() string {
+	return "tcppeers: remote error: " + e.Msg
+}
+
+// Unwrap 使远程 Getter 的加载失败满足 errors.Is(err, geecache.ErrRemoteLoad)，
+// Group 据此直接返回错误而不转移到其他节点
+func (e *RemoteError) Unwrap() error {
+	if e.NotFound {
+		return nil
+	}
+	return geecache.ErrRemoteLoad
+}
+
+// readLoop 读取响应并交给对应的等待者，出错时关闭连接并唤醒所有等待者
+func (cc *clientConn) readLoop() {
+	r := bufio.NewReader(cc.nc)
+	for {
+		res, err := readResponse(r)
+		if err != nil {
+			cc.fail(err)
+			return
+		}
+		cc.mu.Lock()
+		ch, ok := cc.pending[res.id]
+		delete(cc.pending, res.id)
+		cc.mu.Unlock()
+		if ok {
+			ch <- res
+		}
+	}
+}
+
+// fail 把连接标记为断开，唤醒所有等待者（收到 nil 响应）
+func (cc *clientConn) fail(err error) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	if cc.err != nil {
+		return
+	}
+	cc.err = err
+	cc.nc.Close()
+	for id, ch := range cc.pending {
+		ch <- nil
+		delete(cc.pending, id)
+	}
+}
+
+func (cc *clientConn) failure() error {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	return cc.err
+}
+
+func (cc *clientConn) broken() bool {
+	return cc.failure() != nil
+}
+
+var (
+	_ geecache.PeerGetter        = (*tcpGetter)(nil)
+	_ geecache.ContextPeerGetter = (*tcpGetter)(nil)
+)
@@ -0,0 +1,153 @@
+package tcppeers
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// 帧格式（整数均为大端）：
+//
+//	请求：| length uint32 | id uint64 | flags uint8 | timeout uint32 | groupLen uint16 | group | key |
+//	响应：| length uint32 | id uint64 | status uint8 | payload |
+//
+// length 是其后所有字节的长度；id 由客户端分配，响应原样带回，
+// 同一条连接上的多个请求因此可以并发进行、乱序返回。
+// timeout 是客户端 ctx 剩余的毫秒数，0 表示不限制；
+// 响应的 payload 在 status 为 statusOK 时是值，否则是错误信息
+
+const (
+	requestHeaderLen  = 8 + 1 + 4 + 2
+	responseHeaderLen = 8 + 1
+
+	// maxFrameLen 限制单帧大小，避免对端发来错误的长度时分配过多内存
+	maxFrameLen = 64 << 20
+)
+
+// 请求的 flags
+const (
+	flagNoForward = 1 << iota // 收到的节点应在本地加载，不再按哈希环转发
+)
+
+// 响应的 status
+const (
+	statusOK          = iota
+	statusError       // 加载出错
+	statusNotFound    // group 不存在
+	statusTimeout     // 在 timeout 内没有完成
+	statusKeyNotFound // Getter 返回了 geecache.ErrNotFound
+)
+
+var errFrameTooLarge = errors.New("tcppeers: frame too large")
+
+type request struct {
+	id      uint64
+	flags   uint8
+	timeout uint32 // 毫秒
+	group   string
+	key     string
+}
+
+type response struct {
+	id      uint64
+	status  uint8
+	payload []byte
+}
+
+// checkRequest 检查请求能否编码为一帧，不满足时返回的错误只与这个请求有关，与连接无关
+func checkRequest(req *request) error {
+	if len(req.group) > 0xffff {
+		return fmt.Errorf("tcppeers: group name too long: %d bytes", len(req.group))
+	}
+	if requestHeaderLen+len(req.group)+len(req.key) > maxFrameLen {
+		return errFrameTooLarge
+	}
+	return nil
+}
+
+// writeRequest 把请求编码后写入 w，调用方负责 Flush。请求不合法时在写入任何数据之前返回 checkRequest 的错误
+func writeRequest(w *bufio.Writer, req *request) error {
+	if err := checkRequest(req); err != nil {
+		return err
+	}
+	n := requestHeaderLen + len(req.group) + len(req.key)
+	var hdr [4 + requestHeaderLen]byte
+	binary.BigEndian.PutUint32(hdr[0:], uint32(n))
+	binary.BigEndian.PutUint64(hdr[4:], req.id)
+	hdr[12] = req.flags
+	binary.BigEndian.PutUint32(hdr[13:], req.timeout)
+	binary.BigEndian.PutUint16(hdr[17:], uint16(len(req.group)))
+	w.Write(hdr[:])
+	w.WriteString(req.group)
+	_, err := w.WriteString(req.key)
+	return err
+}
+
+// readRequest 从 r 读取并解码一个请求
+func readRequest(r *bufio.Reader) (*request, error) {
+	body, err := readFrame(r, requestHeaderLen)
+	if err != nil {
+		return nil, err
+	}
+	groupLen := int(binary.BigEndian.Uint16(body[13:]))
+	if requestHeaderLen+groupLen > len(body) {
+		return nil, fmt.Errorf("tcppeers: malformed request: group length %d", groupLen)
+	}
+	return &request{
+		id:      binary.BigEndian.Uint64(body[0:]),
+		flags:   body[8],
+		timeout: binary.BigEndian.Uint32(body[9:]),
+		group:   string(body[requestHeaderLen : requestHeaderLen+groupLen]),
+		key:     string(body[requestHeaderLen+groupLen:]),
+	}, nil
+}
+
+// writeResponse 把响应编码后写入 w，调用方负责 Flush
+func writeResponse(w *bufio.Writer, res *response) error {
+	n := responseHeaderLen + len(res.payload)
+	if n > maxFrameLen {
+		return errFrameTooLarge
+	}
+	var hdr [4 + responseHeaderLen]byte
+	binary.BigEndian.PutUint32(hdr[0:], uint32(n))
+	binary.BigEndian.PutUint64(hdr[4:], res.id)
+	hdr[12] = res.status
+	w.Write(hdr[:])
+	_, err := w.Write(res.payload)
+	return err
+}
+
+// readResponse 从 r 读取并解码一个响应，payload 是新分配的，调用方可以直接持有
+func readResponse(r *bufio.Reader) (*response, error) {
+	body, err := readFrame(r, responseHeaderLen)
+	if err != nil {
+		return nil, err
+	}
+	return &response{
+		id:      binary.BigEndian.Uint64(body[0:]),
+		status:  body[8],
+		payload: body[responseHeaderLen:],
+	}, nil
+}
+
+// readFrame 读取一帧的长度前缀和内容，内容至少要有 minLen 字节
+func readFrame(r *bufio.Reader, minLen int) ([]byte, error) {
+	var lenBuf [4]byte
+	if _, err := io.ReadFull(r, lenBuf[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(lenBuf[:])
+	if n > maxFrameLen {
+		return nil, errFrameTooLarge
+	}
+	if int(n) < minLen {
+		return nil, fmt.Errorf("tcppeers: malformed frame: %d bytes", n)
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
@@ -0,0 +1,179 @@
+package tcppeers
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"geecache"
+	"log"
+	"net"
+	"sync"
+	"time"
+)
+
+// Server 在 TCP 连接上接收请求并分发给 geecache.GetGroup(name)。
+// 每个请求在独立的 goroutine 中处理，慢请求不会阻塞同一连接上的其他请求
+type Server struct {
+	mu        sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	closed    bool
+}
+
+// NewServer 创建一个 Server，调用 Serve 开始接收连接
+func NewServer() *Server {
+	return &Server{
+		listeners: make(map[net.Listener]struct{}),
+		conns:     make(map[net.Conn]struct{}),
+	}
+}
+
+// ErrServerClosed 在 Close 之后由 Serve 返回
+var ErrServerClosed = errors.New("tcppeers: server closed")
+
+// Serve 在 lis 上接收连接，直到 lis 出错或 Close 被调用
+func (s *Server) Serve(lis net.Listener) error {
+	if !s.track(lis, nil) {
+		lis.Close()
+		return ErrServerClosed
+	}
+	defer s.untrack(lis, nil)
+
+	for {
+		nc, err := lis.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return ErrServerClosed
+			}
+			return err
+		}
+		if !s.track(nil, nc) {
+			nc.Close()
+			return ErrServerClosed
+		}
+		go s.serveConn(nc)
+	}
+}
+
+// Close 关闭所有监听器和连接，进行中的请求的响应会被丢弃
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	for lis := range s.listeners {
+		lis.Close()
+	}
+	for nc := range s.conns {
+		nc.Close()
+	}
+	return nil
+}
+
+func (s *Server) track(lis net.Listener, nc net.Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return false
+	}
+	if lis != nil {
+		s.listeners[lis] = struct{}{}
+	}
+	if nc != nil {
+		s.conns[nc] = struct{}{}
+	}
+	return true
+}
+
+func (s *Server) untrack(lis net.Listener, nc net.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.listeners, lis)
+	delete(s.conns, nc)
+}
+
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+// serveConn 循环读取请求；连接关闭时取消该连接上所有进行中的请求
+func (s *Server) serveConn(nc net.Conn) {
+	defer s.untrack(nil, nc)
+	defer nc.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var wmu sync.Mutex // 保护 w，多个请求的响应并发写回
+	r, w := bufio.NewReader(nc), bufio.NewWriter(nc)
+	for {
+		req, err := readRequest(r)
+		if err != nil {
+			return
+		}
+		go func() {
+			res := s.handle(ctx, req)
+			wmu.Lock()
+			defer wmu.Unlock()
+			if err := reply(w, res); err != nil {
+				// 写出错后连接上的数据可能已不完整，关闭连接，
+				// 客户端的 readLoop 随之失败并唤醒所有等待者，而不是让它们一直等下去
+				log.Println("[tcppeers] failed to write response", err)
+				nc.Close()
+			}
+		}()
+	}
+}
+
+// reply 把 res 写入 w 并 Flush。值太大无法放进一帧时改为回复 statusError，
+// 客户端得到一个错误而不是一直等不到响应；返回的错误只来自连接的 I/O
+func reply(w *bufio.Writer, res *response) error {
+	err := writeResponse(w, res)
+	if errors.Is(err, errFrameTooLarge) {
+		// writeResponse 在写入任何数据之前就检查了大小，连接上的数据仍然完整
+		msg := fmt.Sprintf("response too large: %d bytes", len(res.payload))
+		err = writeResponse(w, &response{id: res.id, status: statusError, payload: []byte(msg)})
+	}
+	if err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// handle 处理单个请求并构造响应
+func (s *Server) handle(ctx context.Context, req *request) *response {
+	res := &response{id: req.id}
+	group := geecache.GetGroup(req.group)
+	if group == nil {
+		res.status, res.payload = statusNotFound, []byte("no such group: "+req.group)
+		return res
+	}
+	group.Stats.ServerRequests.Add(1)
+
+	if req.flags&flagNoForward != 0 {
+		ctx = geecache.WithNoForward(ctx)
+	}
+	if req.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(req.timeout)*time.Millisecond)
+		defer cancel()
+	}
+
+	view, err := group.GetContext(ctx, req.key)
+	switch {
+	case err == nil:
+		res.status, res.payload = statusOK, view.ByteSlice()
+	case errors.Is(err, geecache.ErrNotFound):
+		res.status, res.payload = statusKeyNotFound, []byte(err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		res.status, res.payload = statusTimeout, []byte(err.Error())
+	default:
+		log.Println("[tcppeers] failed to get", req.group, req.key, err)
+		res.status, res.payload = statusError, []byte(err.Error())
+	}
+	return res
+}
@@ -0,0 +1,108 @@
+// Package tcppeers 实现了一个精简的二进制协议，在节点之间拉取缓存。
+// 与 HTTP/1.1 相比，它在一条长连接上用请求 ID 复用多个并发请求，
+// 每帧只有十几字节的头部，适合大量的小 value。
+// Pool 实现 geecache.PeerPicker，与 HTTPPool 一样基于一致性哈希选点；
+// Server 把请求分发给对应的 Group。用法：
+//
+//	pool := tcppeers.NewPool(self)
+//	pool.Set(peers...)
+//	group.RegisterPeers(pool)
+//
+//	lis, _ := net.Listen("tcp", self)
+//	go tcppeers.NewServer().Serve(lis)
+package tcppeers
+
+import (
+	"fmt"
+	"geecache"
+	"geecache/consistenthash"
+	"log"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	defaultReplicas    = 50
+	defaultDialTimeout = 3 * time.Second
+)
+
+// Pool 维护节点的一致性哈希环，以及到每个远程节点的长连接
+type Pool struct {
+	self string
+	dial func(addr string) (net.Conn, error) // 建立到远程节点的连接，测试中可替换
+
+	mu      sync.Mutex // 保护 peers 和 getters
+	peers   *consistenthash.Map
+	getters map[string]*tcpGetter // 远程节点地址 -> 客户端
+}
+
+// NewPool 创建一个 Pool，self 是本节点的 host:port，需与 Set 中的写法一致
+func NewPool(self string) *Pool {
+	return &Pool{
+		self: self,
+		dial: func(addr string) (net.Conn, error) {
+			return net.DialTimeout("tcp", addr, defaultDialTimeout)
+		},
+	}
+}
+
+func (p *Pool) Log(format string, v ...interface{}) {
+	log.Printf("[TCP Server %s] %s", p.self, fmt.Sprintf(format, v...))
+}
+
+// Set 重建哈希环；连接在第一次请求时建立，不再属于集群的节点的连接会被关闭
+func (p *Pool) Set(peers ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	getters := make(map[string]*tcpGetter, len(peers))
+	for _, peer := range peers {
+		if peer == p.self {
+			continue
+		}
+		if g, ok := p.getters[peer]; ok {
+			getters[peer] = g
+			continue
+		}
+		getters[peer] = &tcpGetter{addr: peer, dial: p.dial}
+	}
+	for peer, g := range p.getters {
+		if getters[peer] != g {
+			g.close()
+		}
+	}
+
+	p.peers = consistenthash.New(defaultReplicas, nil)
+	p.peers.Add(peers...)
+	p.getters = getters
+}
+
+// Close 关闭到所有远程节点的连接
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, g := range p.getters {
+		g.close()
+	}
+	p.peers, p.getters = nil, nil
+	return nil
+}
+
+// PickPeer 根据 key 选出负责它的远程节点，选中自己或没有节点时返回 (nil, false)
+func (p *Pool) PickPeer(key string) (geecache.PeerGetter, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.peers == nil {
+		return nil, false
+	}
+	if peer := p.peers.Get(key); peer != "" && peer != p.self {
+		p.Log("Pick peer %s", peer)
+		return p.getters[peer], true
+	}
+	return nil, false
+}
+
+var _ geecache.PeerPicker = (*Pool)(nil)
@@ -0,0 +1,309 @@
+package tcppeers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"geecache"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestProtocol(t *testing.T) {
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	want := &request{id: 42, flags: flagNoForward, timeout: 1500, group: "scores", key: "Tom/中文"}
+	if err := writeRequest(w, want); err != nil {
+		t.Fatal(err)
+	}
+	writeResponse(w, &response{id: 42, status: statusOK, payload: []byte("630")})
+	w.Flush()
+
+	r := bufio.NewReader(&buf)
+	req, err := readRequest(r)
+	if err != nil || *req != *want {
+		t.Fatalf("request round trip: got %+v %v, want %+v", req, err, want)
+	}
+	res, err := readResponse(r)
+	if err != nil || res.id != 42 || res.status != statusOK || string(res.payload) != "630" {
+		t.Fatalf("response round trip: got %+v %v", res, err)
+	}
+}
+
+func TestMalformedFrame(t *testing.T) {
+	// 长度不足一个请求头
+	r := bufio.NewReader(bytes.NewReader([]byte{0, 0, 0, 1, 0}))
+	if _, err := readRequest(r); err == nil {
+		t.Fatal("expect error for a short frame")
+	}
+	// 长度超过上限
+	r = bufio.NewReader(bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff}))
+	if _, err := readRequest(r); !errors.Is(err, errFrameTooLarge) {
+		t.Fatalf("expect errFrameTooLarge, got %v", err)
+	}
+}
+
+// startServer 在本地回环地址上启动 Server，返回它的地址
+func startServer(t testing.TB) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer()
+	go s.Serve(lis)
+	t.Cleanup(func() { s.Close() })
+	return lis.Addr().String()
+}
+
+// newGetter 返回一个只连接 addr 的 PeerGetter
+func newGetter(t testing.TB, addr string) geecache.PeerGetter {
+	pool := NewPool("self")
+	pool.Set(addr)
+	t.Cleanup(func() { pool.Close() })
+	getter, ok := pool.PickPeer("Tom")
+	if !ok {
+		t.Fatal("expect the only peer to be picked")
+	}
+	return getter
+}
+
+func TestGet(t *testing.T) {
+	var loads int32
+	geecache.NewGroup("tcp-scores", 2<<10, geecache.GetterFunc(
+		func(key string) ([]byte, error) {
+			atomic.AddInt32(&loads, 1)
+			if key == "Tom" {
+				return []byte("630"), nil
+			}
+			if key == "missing" {
+				return nil, fmt.Errorf("%s not exist: %w", key, geecache.ErrNotFound)
+			}
+			return nil, errors.New(key + " not exist")
+		}))
+	getter := newGetter(t, startServer(t))
+
+	for i := 0; i < 2; i++ {
+		if v, err := getter.Get("tcp-scores", "Tom"); err != nil || string(v) != "630" {
+			t.Fatalf("failed to get Tom: %q %v", v, err)
+		}
+	}
+	if loads != 1 {
+		t.Fatalf("expect remote cache hit on second get, loaded %d times", loads)
+	}
+	var re *RemoteError
+	if _, err := getter.Get("tcp-scores", "unknown"); !errors.As(err, &re) || re.NotFound || !errors.Is(err, geecache.ErrRemoteLoad) {
+		t.Fatalf("expect remote error, got %v", err)
+	}
+	if _, err := getter.Get("tcp-scores", "missing"); !errors.Is(err, geecache.ErrNotFound) || err.Error() != "missing not exist: geecache: not found" {
+		t.Fatalf("expect ErrNotFound for a missing key, got %v", err)
+	}
+	if _, err := getter.Get("no-such-group", "Tom"); !errors.As(err, &re) || !re.NotFound {
+		t.Fatalf("expect NotFound for unknown group, got %v", err)
+	}
+}
+
+// TestMultiplex 同一连接上的慢请求不阻塞其他请求
+func TestMultiplex(t *testing.T) {
+	release := make(chan struct{})
+	geecache.NewGroup("tcp-multiplex", 2<<10, geecache.GetterFunc(
+		func(key string) ([]byte, error) {
+			if key == "slow" {
+				<-release
+			}
+			return []byte(key), nil
+		}))
+	getter := newGetter(t, startServer(t))
+
+	slow := make(chan error, 1)
+	go func() {
+		_, err := getter.Get("tcp-multiplex", "slow")
+		slow <- err
+	}()
+
+	var wg sync.WaitGroup
+	for _, key := range []string{"a", "b", "c", "d"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			if v, err := getter.Get("tcp-multiplex", key); err != nil || string(v) != key {
+				t.Errorf("get %s: %q %v", key, v, err)
+			}
+		}(key)
+	}
+	wg.Wait()
+	close(release)
+	if err := <-slow; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestRequestTooLarge key 太大的请求直接失败，同一连接上进行中的请求不受影响
+func TestRequestTooLarge(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	geecache.NewGroup("tcp-large-key", 2<<10, geecache.GetterFunc(
+		func(key string) ([]byte, error) {
+			if key == "slow" {
+				close(started)
+				<-release
+			}
+			return []byte(key), nil
+		}))
+	getter := newGetter(t, startServer(t))
+
+	slow := make(chan error, 1)
+	go func() {
+		_, err := getter.Get("tcp-large-key", "slow")
+		slow <- err
+	}()
+	<-started
+
+	if _, err := getter.Get("tcp-large-key", strings.Repeat("k", maxFrameLen)); !errors.Is(err, errFrameTooLarge) {
+		t.Fatalf("expect errFrameTooLarge, got %v", err)
+	}
+	close(release)
+	if err := <-slow; err != nil {
+		t.Fatalf("in-flight request should survive, got %v", err)
+	}
+	if v, err := getter.Get("tcp-large-key", "fast"); err != nil || string(v) != "fast" {
+		t.Fatalf("connection should still work: %q %v", v, err)
+	}
+}
+
+// TestDeadline 客户端的剩余时长传给远程节点，超时后两端都返回 DeadlineExceeded
+func TestDeadline(t *testing.T) {
+	gotDeadline := make(chan bool, 1)
+	geecache.NewGroup("tcp-deadline", 2<<10, geecache.ContextGetterFunc(
+		func(ctx context.Context, key string) ([]byte, error) {
+			_, ok := ctx.Deadline()
+			gotDeadline <- ok
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}))
+	getter := newGetter(t, startServer(t)).(geecache.ContextPeerGetter)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := getter.GetContext(ctx, "tcp-deadline", "Tom"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expect DeadlineExceeded, got %v", err)
+	}
+	if !<-gotDeadline {
+		t.Fatal("remote getter did not receive a deadline")
+	}
+}
+
+// TestResponseTooLarge 值超过单帧上限时客户端得到错误，连接上的其他请求不受影响
+func TestResponseTooLarge(t *testing.T) {
+	geecache.NewGroup("tcp-large", 2<<10, geecache.GetterFunc(
+		func(key string) ([]byte, error) {
+			if key == "large" {
+				return make([]byte, maxFrameLen), nil
+			}
+			return []byte("v"), nil
+		}))
+	getter := newGetter(t, startServer(t))
+
+	var re *RemoteError
+	if _, err := getter.Get("tcp-large", "large"); !errors.As(err, &re) || !strings.Contains(re.Msg, "too large") {
+		t.Fatalf("expect a remote error for a too large value, got %v", err)
+	}
+	if v, err := getter.Get("tcp-large", "small"); err != nil || string(v) != "v" {
+		t.Fatalf("connection should still work: %q %v", v, err)
+	}
+}
+
+// panicPicker 任何转发都视为错误
+type panicPicker struct{}
+
+func (panicPicker) PickPeer(key string) (geecache.PeerGetter, bool) {
+	panic("unexpected PickPeer for " + key)
+}
+
+func TestNoForward(t *testing.T) {
+	remote := geecache.NewGroup("tcp-no-forward", 2<<10, geecache.GetterFunc(
+		func(key string) ([]byte, error) { return []byte("v"), nil }))
+	remote.RegisterPeers(panicPicker{})
+	getter := newGetter(t, startServer(t)).(geecache.ContextPeerGetter)
+
+	ctx := geecache.WithNoForward(context.Background())
+	if v, err := getter.GetContext(ctx, "tcp-no-forward", "Tom"); err != nil || string(v) != "v" {
+		t.Fatalf("failed to get without forwarding: %q %v", v, err)
+	}
+}
+
+// TestReconnect 连接断开后，下一次请求重新建立连接
+func TestReconnect(t *testing.T) {
+	geecache.NewGroup("tcp-reconnect", 2<<10, geecache.GetterFunc(
+		func(key string) ([]byte, error) { return []byte(key), nil }))
+	getter := newGetter(t, startServer(t)).(*tcpGetter)
+
+	if _, err := getter.Get("tcp-reconnect", "a"); err != nil {
+		t.Fatal(err)
+	}
+	first := getter.conn
+	first.nc.Close()
+	for first.failure() == nil {
+		time.Sleep(time.Millisecond)
+	}
+	if v, err := getter.Get("tcp-reconnect", "b"); err != nil || string(v) != "b" {
+		t.Fatalf("failed to get after reconnect: %q %v", v, err)
+	}
+	if getter.conn == first {
+		t.Fatal("expect a new connection")
+	}
+}
+
+// 以下基准对比同一个小 value 通过 tcppeers 和 HTTPPool 拉取的开销。
+// 值在远程节点的缓存中，测量的基本是协议和网络栈本身
+
+func benchGroup(b *testing.B) {
+	if geecache.GetGroup("bench") == nil {
+		geecache.NewGroup("bench", 2<<10, geecache.GetterFunc(
+			func(key string) ([]byte, error) { return []byte("630"), nil }))
+	}
+}
+
+func benchGetter(b *testing.B, getter geecache.PeerGetter, parallel bool) {
+	if _, err := getter.Get("bench", "Tom"); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	if !parallel {
+		for i := 0; i < b.N; i++ {
+			getter.Get("bench", "Tom")
+		}
+		return
+	}
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			getter.Get("bench", "Tom")
+		}
+	})
+}
+
+func newHTTPGetter(b *testing.B) geecache.PeerGetter {
+	benchGroup(b)
+	srv := httptest.NewServer(geecache.NewHTTPPool("remote"))
+	b.Cleanup(srv.Close)
+	pool := geecache.NewHTTPPool("self")
+	pool.Set(srv.URL)
+	getter, _ := pool.PickPeer("Tom")
+	return getter
+}
+
+func newTCPGetter(b *testing.B) geecache.PeerGetter {
+	benchGroup(b)
+	return newGetter(b, startServer(b))
+}
+
+func BenchmarkTCPGetter(b *testing.B)          { benchGetter(b, newTCPGetter(b), false) }
+func BenchmarkHTTPGetter(b *testing.B)         { benchGetter(b, newHTTPGetter(b), false) }
+func BenchmarkTCPGetterParallel(b *testing.B)  { benchGetter(b, newTCPGetter(b), true) }
+func BenchmarkHTTPGetterParallel(b *testing.B) { benchGetter(b, newHTTPGetter(b), true) }
@@ -0,0 +1,129 @@
+package tinylfu
+
+import "hash/fnv"
+
+const (
+	sketchDepth = 4  // count-min sketch 的行数，每行用不同的哈希
+	maxCount    = 15 // 计数器上限，和 4 位计数器一样，足够区分冷热
+)
+
+// sketch 是带老化和门卫（doorkeeper）的 count-min sketch，用很小的内存近似统计 key 的访问频率。
+//
+// 第一次出现的 key 只记录在门卫布隆过滤器里，再次出现才进入计数器，
+// 避免大量只出现一次的 key 占满计数器。每累计 sampleSize 次访问，所有计数器减半并清空门卫，
+// 让很久以前的热点逐渐"冷却"。
+type sketch struct {
+	rows       [sketchDepth][]uint8
+	mask       uint32
+	door       []uint64 // 门卫布隆过滤器的位图
+	doorMask   uint32
+	additions  int
+	sampleSize int
+}
+
+// newSketch 创建能较准确统计约 width 个不同 key 的 sketch，width 会向上取整到 2 的幂
+func newSketch(width int) *sketch {
+	w := nextPowerOfTwo(width)
+	s := &sketch{
+		mask:       uint32(w - 1),
+		door:       make([]uint64, w/8+1), // 每个 key 约 8 位
+		doorMask:   uint32(w*8 - 1),
+		sampleSize: 10 * w,
+	}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, w)
+	}
+	return s
+}
+
+// increment 记录一次对 key 的访问
+func (s *sketch) increment(key string) {
+	h1, h2 := hash(key)
+	if !s.doorAdd(h1, h2) {
+		// 第一次出现，只进门卫
+		s.tick()
+		return
+	}
+	for i := range s.rows {
+		idx := (h1 + uint32(i)*h2) & s.mask
+		if s.rows[i][idx] < maxCount {
+			s.rows[i][idx]++
+		}
+	}
+	s.tick()
+}
+
+// estimate 返回 key 访问频率的估计值（各行计数器的最小值，加上门卫中的 1 次）
+func (s *sketch) estimate(key string) int {
+	h1, h2 := hash(key)
+	n := maxCount + 1
+	for i := range s.rows {
+		if c := int(s.rows[i][(h1+uint32(i)*h2)&s.mask]); c < n {
+			n = c
+		}
+	}
+	if s.doorContains(h1, h2) {
+		n++
+	}
+	return n
+}
+
+// tick 累计访问次数，达到 sampleSize 时老化
+func (s *sketch) tick() {
+	s.additions++
+	if s.additions >= s.sampleSize {
+		s.reset()
+	}
+}
+
+// reset 所有计数器减半，并清空门卫
+func (s *sketch) reset() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] >>= 1
+		}
+	}
+	for i := range s.door {
+		s.door[i] = 0
+	}
+	s.additions /= 2
+}
+
+// doorAdd 把 key 加入门卫，返回加入前 key 是否已经存在
+func (s *sketch) doorAdd(h1, h2 uint32) bool {
+	present := true
+	for i := uint32(0); i < 2; i++ {
+		bit := (h1 + i*h2) & s.doorMask
+		if s.door[bit/64]&(1<<(bit%64)) == 0 {
+			present = false
+			s.door[bit/64] |= 1 << (bit % 64)
+		}
+	}
+	return present
+}
+
+func (s *sketch) doorContains(h1, h2 uint32) bool {
+	for i := uint32(0); i < 2; i++ {
+		bit := (h1 + i*h2) & s.doorMask
+		if s.door[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// hash 用一次 64 位 FNV 哈希派生出两个 32 位哈希，再组合出多个哈希函数
+func hash(key string) (uint32, uint32) {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	sum := h.Sum64()
+	return uint32(sum), uint32(sum>>32) | 1 // h2 为奇数，保证遍历不同的位置
+}
+
+func nextPowerOfTwo(n int) int {
+	p := 1
+	for p < n {
+		p <<= 1
+	}
+	return p
+}
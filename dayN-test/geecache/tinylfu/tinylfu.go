@@ -0,0 +1,189 @@
+// Package tinylfu 在任意 lru.Store 前面加上 W-TinyLFU 准入策略。
+//
+// 新记录先进入一个很小的 LRU 窗口（约占 1% 容量），从窗口淘汰出来的记录作为候选者，
+// 只有当它的估计访问频率高于主缓存下一个淘汰对象（victim）时才会进入主缓存，否则直接丢弃。
+// 这样一次性的批量扫描无法把主缓存中的热点数据挤出去。
+package tinylfu
+
+import (
+	"fmt"
+	"geecache/lru"
+	"time"
+)
+
+const windowFraction = 100 // 窗口占总容量的 1/100
+
+type Cache struct {
+	window    *lru.Cache
+	main      lru.Store
+	mainBytes int64 // 主缓存的容量
+	sketch    *sketch
+	OnEvicted func(key string, value lru.Value)
+	// OnEvictedWithReason 与 OnEvicted 相同，但额外告知淘汰原因；
+	// 主缓存的 Store 未实现 lru.ReasonNotifier 时，它淘汰的记录都报告为 lru.EvictCapacity
+	OnEvictedWithReason func(key string, value lru.Value, reason lru.EvictReason)
+	now                 func() time.Time
+	mainReasons         bool // 主缓存是否通过 lru.ReasonNotifier 报告淘汰原因
+}
+
+// windowValue 记录窗口中记录的过期时间，进入主缓存时据此计算剩余 TTL
+type windowValue struct {
+	lru.Value
+	expire time.Time
+}
+
+// New 创建容量为 maxBytes 的 W-TinyLFU 缓存，主缓存由 policy 创建。
+// maxBytes 为 0（不限容量）时不需要准入，直接返回 policy 创建的 Store。
+// policy 创建的 Store 必须实现 lru.Victimer（lru、lfu、arc 都实现了），否则 panic
+func New(maxBytes int64, policy lru.Policy, onEvicted func(string, lru.Value)) lru.Store {
+	if maxBytes == 0 {
+		return policy(maxBytes, onEvicted)
+	}
+	windowBytes := max(maxBytes/windowFraction, 1)
+	c := &Cache{
+		mainBytes: maxBytes - windowBytes,
+		sketch:    newSketch(int(min(max(maxBytes/32, 1024), 1<<22))),
+		OnEvicted: onEvicted,
+		now:       time.Now,
+	}
+	c.window = lru.New(windowBytes, nil)
+	c.window.OnEvictedWithReason = c.onWindowEvicted
+	c.main = policy(c.mainBytes, func(key string, value lru.Value) {
+		if !c.mainReasons {
+			c.evicted(key, value, lru.EvictCapacity)
+		}
+	})
+	if _, ok := c.main.(lru.Victimer); !ok {
+		panic(fmt.Sprintf("tinylfu: %T does not implement lru.Victimer", c.main))
+	}
+	if rn, ok := c.main.(lru.ReasonNotifier); ok {
+		c.mainReasons = true
+		rn.SetOnEvictedWithReason(c.evicted)
+	}
+	return c
+}
+
+// Wrap 返回在 policy 前面加上 W-TinyLFU 准入策略的 Policy
+func Wrap(policy lru.Policy) lru.Policy {
+	return func(maxBytes int64, onEvicted func(string, lru.Value)) lru.Store {
+		return New(maxBytes, policy, onEvicted)
+	}
+}
+
+var (
+	_ lru.Store          = (*Cache)(nil)
+	_ lru.Clocked        = (*Cache)(nil)
+	_ lru.ReasonNotifier = (*Cache)(nil)
+)
+
+// Add 添加一条永不过期的记录
+func (c *Cache) Add(key string, value lru.Value) {
+	c.AddWithTTL(key, value, 0)
+}
+
+// AddWithTTL 更新已有记录，或把新记录放入窗口。
+// 用 Contains 判断记录是否在主缓存中，不会让主缓存的淘汰策略把这次写入算作一次访问
+func (c *Cache) AddWithTTL(key string, value lru.Value, ttl time.Duration) {
+	if c.main.Contains(key) {
+		c.main.AddWithTTL(key, value, ttl)
+		return
+	}
+	var expire time.Time
+	if ttl > 0 {
+		expire = c.now().Add(ttl)
+	}
+	c.window.AddWithTTL(key, windowValue{value, expire}, ttl)
+}
+
+// Get 记录一次访问并依次查找窗口和主缓存
+func (c *Cache) Get(key string) (value lru.Value, ok bool) {
+	c.sketch.increment(key)
+	if v, ok := c.window.Get(key); ok {
+		return v.(windowValue).Value, true
+	}
+	return c.main.Get(key)
+}
+
+// Contains 判断 key 是否在窗口或主缓存中，不记录访问
+func (c *Cache) Contains(key string) bool {
+	return c.window.Contains(key) || c.main.Contains(key)
+}
+
+// Remove 从窗口或主缓存中删除 key，访问频率的估计保留
+func (c *Cache) Remove(key string) bool {
+	return c.window.Remove(key) || c.main.Remove(key)
+}
+
+// RemoveExpired 删除窗口和主缓存中所有已过期的记录，返回回收的字节数
+func (c *Cache) RemoveExpired() int64 {
+	return c.window.RemoveExpired() + c.main.RemoveExpired()
+}
+
+func (c *Cache) Len() int {
+	return c.window.Len() + c.main.Len()
+}
+
+func (c *Cache) Bytes() int64 {
+	return c.window.Bytes() + c.main.Bytes()
+}
+
+// SetOnEvictedWithReason 设置 OnEvictedWithReason（实现了 lru.ReasonNotifier 接口）
+func (c *Cache) SetOnEvictedWithReason(fn func(key string, value lru.Value, reason lru.EvictReason)) {
+	c.OnEvictedWithReason = fn
+}
+
+// SetNow 替换窗口、主缓存和计算剩余 TTL 用的时钟，nil 表示使用 time.Now（实现了 lru.Clocked 接口）
+func (c *Cache) SetNow(now func() time.Time) {
+	c.window.SetNow(now)
+	if cl, ok := c.main.(lru.Clocked); ok {
+		cl.SetNow(now)
+	}
+	if now == nil {
+		now = time.Now
+	}
+	c.now = now
+}
+
+// onWindowEvicted 处理从窗口淘汰出来的候选者：主缓存有空间则直接放入，
+// 否则与主缓存的 victim 比较访问频率，频率更高才放入
+func (c *Cache) onWindowEvicted(key string, value lru.Value, reason lru.EvictReason) {
+	wv := value.(windowValue)
+	var ttl time.Duration
+	if !wv.expire.IsZero() {
+		ttl = wv.expire.Sub(c.now())
+		if ttl <= 0 {
+			reason = lru.EvictExpired
+		}
+	}
+	if reason == lru.EvictExpired {
+		c.evicted(key, wv.Value, reason)
+		return
+	}
+	if !c.admit(key, int64(len(key)+wv.Len())) {
+		c.evicted(key, wv.Value, lru.EvictCapacity)
+		return
+	}
+	c.main.AddWithTTL(key, wv.Value, ttl)
+}
+
+// admit 判断候选者是否能进入主缓存。
+// 主缓存的 Store 必须实现 lru.Victimer，否则无法比较，New 会直接 panic
+func (c *Cache) admit(key string, size int64) bool {
+	if c.main.Bytes()+size <= c.mainBytes {
+		return true
+	}
+	victim, ok := c.main.(lru.Victimer).Victim()
+	if !ok {
+		return true
+	}
+	return c.sketch.estimate(key) > c.sketch.estimate(victim)
+}
+
+func (c *Cache) evicted(key string, value lru.Value, reason lru.EvictReason) {
+	if c.OnEvicted != nil {
+		c.OnEvicted(key, value)
+	}
+	if c.OnEvictedWithReason != nil {
+		c.OnEvictedWithReason(key, value, reason)
+	}
+}
@@ -0,0 +1,156 @@
+package tinylfu
+
+import (
+	"fmt"
+	"geecache/arc"
+	"geecache/lfu"
+	"geecache/lru"
+	"geecache/lru/storetest"
+	"testing"
+	"time"
+)
+
+type String string
+
+func (d String) Len() int {
+	return len(d)
+}
+
+func TestStore(t *testing.T) {
+	storetest.TestStore(t, Wrap(lru.NewStore))
+}
+
+func TestSketch(t *testing.T) {
+	s := newSketch(64)
+	if n := s.estimate("a"); n != 0 {
+		t.Fatalf("estimate of unseen key = %d, want 0", n)
+	}
+	s.increment("a") // 第一次只进门卫
+	if n := s.estimate("a"); n != 1 {
+		t.Fatalf("estimate after 1 increment = %d, want 1", n)
+	}
+	for i := 0; i < 3; i++ {
+		s.increment("a")
+	}
+	if n := s.estimate("a"); n != 4 {
+		t.Fatalf("estimate after 4 increments = %d, want 4", n)
+	}
+	for i := 0; i < 100; i++ {
+		s.increment("b")
+	}
+	if n := s.estimate("b"); n > maxCount+1 {
+		t.Fatalf("estimate %d exceeds counter limit", n)
+	}
+}
+
+// TestSketchReset 达到 sampleSize 后计数器减半，门卫被清空
+func TestSketchReset(t *testing.T) {
+	s := newSketch(64)
+	for i := 0; i < 9; i++ {
+		s.increment("a")
+	}
+	before := s.estimate("a")
+	for i := s.additions; i < s.sampleSize; i++ {
+		s.increment(fmt.Sprintf("k%d", i))
+	}
+	if after := s.estimate("a"); after >= before {
+		t.Fatalf("estimate should decay after reset: before %d, after %d", before, after)
+	}
+}
+
+// TestAdmission 一次性扫描的 key 访问频率低，不能挤掉主缓存中的热点数据
+func TestAdmission(t *testing.T) {
+	policies := map[string]lru.Policy{"lru": lru.NewStore, "lfu": lfu.NewStore, "arc": arc.NewStore}
+	for name, policy := range policies {
+		t.Run(name, func(t *testing.T) { testAdmission(t, policy) })
+	}
+}
+
+func testAdmission(t *testing.T, policy lru.Policy) {
+	const entrySize = int64(len("hot0") + len("v"))
+	s := New(entrySize*100, policy, nil)
+	hot := make([]string, 50)
+	for i := range hot {
+		hot[i] = fmt.Sprintf("hot%d", i)
+	}
+	for round := 0; round < 5; round++ {
+		for _, k := range hot {
+			if _, ok := s.Get(k); !ok {
+				s.Add(k, String("v"))
+			}
+		}
+	}
+	for i := 0; i < 1000; i++ {
+		k := fmt.Sprintf("s%03d", i)
+		if _, ok := s.Get(k); !ok {
+			s.Add(k, String("v"))
+		}
+	}
+	for _, k := range hot {
+		if _, ok := s.Get(k); !ok {
+			t.Fatalf("hot key %s was evicted by scan", k)
+		}
+	}
+}
+
+// TestWindowTTL 从窗口进入主缓存的记录保留剩余的 TTL
+func TestWindowTTL(t *testing.T) {
+	s := New(400, lru.NewStore, nil).(*Cache)
+	s.AddWithTTL("k1", String("v1"), 20*time.Millisecond)
+	s.Add("k2", String("v2")) // 窗口只有 4 字节，k1 被挤入主缓存
+	if _, ok := s.main.Get("k1"); !ok {
+		t.Fatalf("k1 should be admitted into main cache")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if _, ok := s.Get("k1"); ok {
+		t.Fatalf("k1 should expire in main cache")
+	}
+}
+
+// spyStore 统计主缓存收到的 Get 次数
+type spyStore struct {
+	*lru.Cache
+	gets int
+}
+
+func (s *spyStore) Get(key string) (lru.Value, bool) {
+	s.gets++
+	return s.Cache.Get(key)
+}
+
+// TestUpdateNoAccess 更新主缓存中已有的记录不算一次访问
+func TestUpdateNoAccess(t *testing.T) {
+	var spy *spyStore
+	s := New(400, func(maxBytes int64, onEvicted func(string, lru.Value)) lru.Store {
+		spy = &spyStore{Cache: lru.New(maxBytes, onEvicted)}
+		return spy
+	}, nil).(*Cache)
+	s.Add("k1", String("v1"))
+	s.Add("k2", String("v2")) // k1 被挤入主缓存
+	if !s.main.Contains("k1") {
+		t.Fatal("k1 should be admitted into main cache")
+	}
+	s.Add("k1", String("v3"))
+	if spy.gets != 0 {
+		t.Fatalf("Add should not Get from main cache, got %d gets", spy.gets)
+	}
+	if v, ok := s.Get("k1"); !ok || v.(String) != "v3" {
+		t.Fatalf("expect updated k1, got %v %v", v, ok)
+	}
+}
+
+// noVictimStore 不实现 lru.Victimer
+type noVictimStore struct {
+	lru.Store
+}
+
+func TestRequireVictimer(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expect panic for a store without Victim")
+		}
+	}()
+	New(400, func(maxBytes int64, onEvicted func(string, lru.Value)) lru.Store {
+		return noVictimStore{lru.New(maxBytes, onEvicted)}
+	}, nil)
+}
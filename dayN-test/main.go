@@ -5,15 +5,17 @@ $ curl "http://localhost:9999/api?key=Tom"
 630
 
 $ curl "http://localhost:9999/api?key=kkk"
-kkk not exist
+kkk not exist: geecache: not found
 */
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"geecache"
 	"log"
 	"net/http"
+	"time"
 )
 
 // db 模拟了一个“慢速数据库”或外部存储。
@@ -30,7 +32,9 @@ var db = map[string]string{
 // - 容量 2<<10 (~2048 bytes)：LRU 缓存的上限，超过会淘汰最老数据。
 // - 回调函数：通过 GetterFunc 适配器，把普通函数包装为 Getter，当本地/远程都无命中时调用：
 //   - 1. 在控制台打印 [SlowDB] search key X，模拟慢查询。
-//   - 2. 查 db，存在返回值字节，不存在返回错误。
+//   - 2. 查 db，存在返回值字节，不存在返回包装了 geecache.ErrNotFound 的错误。
+//
+// 不存在的 key 在负缓存中保留 5 秒，期间重复请求不会打到 SlowDB。
 func createGroup() *geecache.Group {
 	return geecache.NewGroup("scores", 2<<10, geecache.GetterFunc(
 		func(key string) ([]byte, error) {
@@ -38,8 +42,8 @@ func createGroup() *geecache.Group {
 			if v, ok := db[key]; ok {
 				return []byte(v), nil
 			}
-			return nil, fmt.Errorf("%s not exist", key)
-		}))
+			return nil, fmt.Errorf("%s not exist: %w", key, geecache.ErrNotFound)
+		}), geecache.WithNegativeCache(5*time.Second, 0))
 }
 
 // startCacheServer 启动缓存节点
@@ -54,10 +58,15 @@ func startCacheServer(addr string, addrs []string, gee *geecache.Group) {
 	// 3. 将 HTTPPool 注入到 Group（依赖注入），启用分布式获取能力
 	gee.RegisterPeers(peers)
 
-	log.Println("geecache is running at", addr)
-	// 4. 启动 HTTP 服务，监听 addr[7:] 端口（去掉前缀"http://"），所有路由交给 peers 处理
+	// 4. 路由：/_geecache/ 交给 peers 处理，/metrics 暴露 Prometheus 指标
 	//    peers.ServeHTTP 负责 /_geecache/<group>/<key> 路由
-	log.Fatal(http.ListenAndServe(addr[7:], peers))
+	mux := http.NewServeMux()
+	mux.Handle("/_geecache/", peers)
+	mux.Handle("/metrics", geecache.MetricsHandler(peers))
+
+	log.Println("geecache is running at", addr)
+	// 5. 启动 HTTP 服务，监听 addr[7:] 端口（去掉前缀"http://"）
+	log.Fatal(http.ListenAndServe(addr[7:], mux))
 }
 
 // startAPIServer 启用前端HTTP服务，暴露 /api?key= 供外部客户端（例如 curl）通过 HTTP 接口访问 GeeCache。
@@ -68,11 +77,15 @@ func startAPIServer(apiAddr string, gee *geecache.Group) {
 		func(w http.ResponseWriter, r *http.Request) {
 			// 1. 从 URL 获取 key 参数
 			key := r.URL.Query().Get("key")
-			// 2. 调用分布式缓存获取数据（本地->远程->回源）
-			view, err := gee.Get(key)
+			// 2. 调用分布式缓存获取数据（本地->远程->回源），客户端断开时 r.Context() 会被取消
+			view, err := gee.GetContext(r.Context(), key)
 			if err != nil {
-				// 4. 获取失败，返回 500 和错误信息
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				// 4. 获取失败，key 不存在返回 404，其他错误返回 500
+				status := http.StatusInternalServerError
+				if errors.Is(err, geecache.ErrNotFound) {
+					status = http.StatusNotFound
+				}
+				http.Error(w, err.Error(), status)
 				return
 			}
 			// 3. 成功则以二进制形式返回数据